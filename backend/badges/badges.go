@@ -0,0 +1,54 @@
+package badges
+
+import (
+	"log"
+	"time"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/domain"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/storage"
+)
+
+// Service awards the badges with a rule when the activity of the users changes, the admins award the rest by hand
+type Service struct {
+	store storage.Storage
+}
+
+func NewService(store storage.Storage) *Service {
+	return &Service{
+		store: store,
+	}
+}
+
+// Name of the service among the subscribers of the domain events
+const subscriberName = "badges"
+
+// Subscribe evaluates the rules of the user whose counts changed with the domain event, the events attended
+// depend on the time so they are left to EvaluateAll
+func (s *Service) Subscribe(bus *domain.Bus) {
+	domain.SubscribeAsync(bus, subscriberName, func(e domain.PostCreated) { s.Evaluate(e.Post.User.ID) })
+	domain.SubscribeAsync(bus, subscriberName, func(e domain.EventCreated) { s.Evaluate(e.Event.Creator.ID) })
+	domain.SubscribeAsync(bus, subscriberName, func(e domain.UserFollowed) { s.Evaluate(e.FollowedID) })
+	domain.SubscribeAsync(bus, subscriberName, func(e domain.PostLiked) {
+		ownerID, err := s.store.GetPostOwnerID(e.PostID)
+		if err != nil {
+			log.Println("badges: could not get the post owner:", err)
+			return
+		}
+		s.Evaluate(ownerID)
+	})
+}
+
+func (s *Service) Evaluate(userID int) {
+	if _, err := s.store.AwardRuleBadges(userID); err != nil {
+		log.Println("badges: could not award the badges:", err)
+	}
+}
+
+// EvaluateAll awards the badges of every user, it runs as a scheduled task
+func (s *Service) EvaluateAll(now time.Time) error {
+	awarded, err := s.store.AwardAllRuleBadges()
+	if awarded > 0 {
+		log.Printf("badges: %d badges awarded\n", awarded)
+	}
+	return err
+}
@@ -0,0 +1,175 @@
+package digest
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/jobs"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/mailer"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/storage"
+)
+
+// Max items of every section of the digest
+const sectionLimit = 5
+
+//go:embed templates
+var templatesFS embed.FS
+
+var (
+	htmlTemplate = htmltemplate.Must(htmltemplate.ParseFS(templatesFS, "templates/digest.html"))
+	textTemplate = texttemplate.Must(texttemplate.ParseFS(templatesFS, "templates/digest.txt"))
+)
+
+// Job type of the email of one digest
+const sendJob = "send-digest"
+
+// Job emails the activity digest to the users that opted in
+type Job struct {
+	store  storage.Storage
+	mailer mailer.Mailer
+	queue  *jobs.Queue
+}
+
+// sendPayload is the period of one user to summarize, the user is read again when the email is sent
+type sendPayload struct {
+	UserID    int       `json:"user_id"`
+	Frequency string    `json:"frequency"`
+	Since     time.Time `json:"since"`
+	Until     time.Time `json:"until"`
+}
+
+func NewJob(store storage.Storage, m mailer.Mailer, queue *jobs.Queue) *Job {
+	j := &Job{
+		store:  store,
+		mailer: m,
+		queue:  queue,
+	}
+
+	jobs.Register(queue, sendJob, j.send)
+
+	return j
+}
+
+// Run queues an email for every digest that is due, it returns how many were queued.
+// The queue retries the emails that fail, so a slow or failing mailer never blocks the scheduled task.
+// The period is closed by the send job once the email is delivered, a digest that could not be sent is queued again
+func (j *Job) Run(now time.Time) (int, error) {
+	recipients, err := j.store.GetDueDigestRecipients(now)
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, recipient := range recipients {
+		payload := sendPayload{UserID: recipient.User.ID, Frequency: recipient.Frequency, Since: recipient.Since, Until: now}
+		if _, err := j.queue.Enqueue(sendJob, payload); err != nil {
+			log.Println("digest: could not queue the digest:", err)
+			continue
+		}
+		queued++
+	}
+
+	return queued, nil
+}
+
+// send builds and emails one digest and closes its period, empty digests are not emailed but close it too
+func (j *Job) send(job *models.Job, p sendPayload) error {
+	user, err := j.store.GetUserByID(p.UserID)
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		return nil
+	}
+
+	digest, err := j.build(models.DigestRecipient{User: *user, Frequency: p.Frequency, Since: p.Since})
+	if err != nil {
+		return fmt.Errorf("could not build the digest: %w", err)
+	}
+
+	if !digest.IsEmpty() {
+		msg, err := Render(digest)
+		if err != nil {
+			return fmt.Errorf("could not render the digest: %w", err)
+		}
+
+		if err := j.mailer.Send(msg); err != nil {
+			return err
+		}
+	}
+
+	return j.store.MarkDigestSent(p.UserID, p.Until)
+}
+
+// build collects the sections of the digest the user wants to receive by email
+func (j *Job) build(recipient models.DigestRecipient) (*models.Digest, error) {
+	userID := recipient.User.ID
+
+	preferences, err := j.store.GetNotificationPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := &models.Digest{
+		User:      recipient.User,
+		Frequency: recipient.Frequency,
+		Since:     recipient.Since,
+	}
+
+	if preferences[models.NotificationUserFollowed][models.NotificationChannelEmail] {
+		if digest.NewFollowers, err = j.store.GetNewFollowers(userID, recipient.Since, sectionLimit); err != nil {
+			return nil, err
+		}
+	}
+
+	if digest.TopPosts, err = j.store.GetTopFeedPosts(userID, recipient.Since, sectionLimit); err != nil {
+		return nil, err
+	}
+
+	if preferences[models.NotificationEventReminder][models.NotificationChannelEmail] {
+		if digest.UpcomingEvents, err = j.store.GetUpcomingSubscribedEvents(userID, sectionLimit); err != nil {
+			return nil, err
+		}
+	}
+
+	if preferences[models.NotificationMessage][models.NotificationChannelEmail] {
+		count, err := j.store.GetUnreadMessagesCount(userID)
+		if err != nil {
+			return nil, err
+		}
+		digest.UnreadMessages = *count
+	}
+
+	return digest, nil
+}
+
+// Render builds the email of the digest with its text and html versions
+func Render(digest *models.Digest) (*mailer.Message, error) {
+	var html, text bytes.Buffer
+
+	if err := htmlTemplate.Execute(&html, digest); err != nil {
+		return nil, err
+	}
+
+	if err := textTemplate.Execute(&text, digest); err != nil {
+		return nil, err
+	}
+
+	subject := "Your daily activity summary"
+	if digest.Frequency == models.DigestWeekly {
+		subject = "Your weekly activity summary"
+	}
+
+	return &mailer.Message{
+		To:      digest.User.Email,
+		Subject: subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,42 @@
+package digest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+)
+
+func TestRender(t *testing.T) {
+	digest := &models.Digest{
+		User:           models.User{FullName: "Ana <Runner>", Email: "ana@example.com"},
+		Frequency:      models.DigestWeekly,
+		Since:          time.Date(2025, time.March, 3, 0, 0, 0, 0, time.UTC),
+		NewFollowers:   []models.PublicUser{{UserName: "marc", FullName: "Marc"}},
+		UnreadMessages: 3,
+	}
+
+	msg, err := Render(digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.To != "ana@example.com" || msg.Subject != "Your weekly activity summary" {
+		t.Errorf("unexpected headers: %q %q", msg.To, msg.Subject)
+	}
+
+	for _, want := range []string{"Marc (@marc)", "3 unread messages", "since Mar 3"} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("text version does not contain %q:\n%s", want, msg.Text)
+		}
+	}
+
+	if !strings.Contains(msg.HTML, "Ana &lt;Runner&gt;") {
+		t.Errorf("html version is not escaped:\n%s", msg.HTML)
+	}
+
+	if strings.Contains(msg.Text, "Upcoming events") {
+		t.Errorf("empty sections should not be rendered:\n%s", msg.Text)
+	}
+}
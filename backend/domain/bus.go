@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+)
+
+type subscriber struct {
+	name    string
+	handler func(Event) error
+	async   bool
+}
+
+// Bus delivers the domain events to the subscribers of their type. Sync subscribers run in
+// the goroutine of the publisher in the order they subscribed, async ones in their own goroutine
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[string][]subscriber
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		subscribers: make(map[string][]subscriber),
+	}
+}
+
+// Subscribe registers a handler that runs before Publish returns, its error is returned by Publish
+// so the relay of the outbox retries the event. The name identifies the subscriber among the ones of
+// the event type, a retried event only runs the subscribers that did not get it yet
+func Subscribe[E Event](b *Bus, name string, handler func(E) error) {
+	subscribe[E](b, name, func(e Event) error { return handler(e.(E)) }, false)
+}
+
+// SubscribeAsync registers a handler that runs in the background, its failures are only logged
+func SubscribeAsync[E Event](b *Bus, name string, handler func(E)) {
+	subscribe[E](b, name, func(e Event) error {
+		handler(e.(E))
+		return nil
+	}, true)
+}
+
+func subscribe[E Event](b *Bus, name string, handler func(Event) error, async bool) {
+	var zero E
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, s := range b.subscribers[zero.EventName()] {
+		if s.name == name {
+			panic(fmt.Sprintf("domain: %s already has a subscriber named %s", zero.EventName(), name))
+		}
+	}
+
+	b.subscribers[zero.EventName()] = append(b.subscribers[zero.EventName()], subscriber{
+		name:    name,
+		handler: handler,
+		async:   async,
+	})
+}
+
+// Delivery is the result of delivering an event, async subscribers count as delivered once they start
+type Delivery struct {
+	Delivered []string
+	// Err joins the errors of the sync subscribers that failed
+	Err error
+}
+
+// Publish delivers the event to every subscriber and returns the errors of the sync ones, a failing
+// subscriber never stops the other subscribers
+func (b *Bus) Publish(e Event) error {
+	return b.Deliver(e, nil).Err
+}
+
+// Deliver runs the subscribers of the event except the ones in skip, that already got it
+func (b *Bus) Deliver(e Event, skip []string) Delivery {
+	b.mu.RLock()
+	subscribers := b.subscribers[e.EventName()]
+	b.mu.RUnlock()
+
+	var delivery Delivery
+	var errs []error
+	for _, s := range subscribers {
+		if slices.Contains(skip, s.name) {
+			continue
+		}
+
+		if s.async {
+			go func() {
+				if err := run(s, e); err != nil {
+					log.Println("domain:", err)
+				}
+			}()
+			delivery.Delivered = append(delivery.Delivered, s.name)
+			continue
+		}
+
+		if err := run(s, e); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delivery.Delivered = append(delivery.Delivered, s.name)
+	}
+
+	delivery.Err = errors.Join(errs...)
+	return delivery
+}
+
+func run(s subscriber, e Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("subscriber %s of %s panicked: %v", s.name, e.EventName(), r)
+		}
+	}()
+
+	if err := s.handler(e); err != nil {
+		return fmt.Errorf("subscriber %s of %s failed: %w", s.name, e.EventName(), err)
+	}
+
+	return nil
+}
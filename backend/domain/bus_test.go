@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPublishSync(t *testing.T) {
+	bus := NewBus()
+
+	var calls []string
+	Subscribe(bus, "first", func(e UserFollowed) error {
+		calls = append(calls, "first")
+		return nil
+	})
+	Subscribe(bus, "second", func(e UserFollowed) error { panic("boom") })
+	Subscribe(bus, "third", func(e UserFollowed) error {
+		calls = append(calls, "third")
+		return errors.New("third failed")
+	})
+	Subscribe(bus, "first", func(e PostLiked) error {
+		calls = append(calls, "other type")
+		return nil
+	})
+
+	err := bus.Publish(UserFollowed{FollowerID: 1, FollowedID: 2})
+
+	if len(calls) != 2 || calls[0] != "first" || calls[1] != "third" {
+		t.Errorf("unexpected calls %v", calls)
+	}
+
+	// The panic and the error are both returned to the publisher
+	if err == nil || !strings.Contains(err.Error(), "boom") || !strings.Contains(err.Error(), "third failed") {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	if err := bus.Publish(PostLiked{UserID: 1, PostID: 2}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestPublishAsync(t *testing.T) {
+	bus := NewBus()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	var got EventSubscribed
+	SubscribeAsync(bus, "async", func(e EventSubscribed) {
+		got = e
+		wg.Done()
+	})
+
+	bus.Publish(EventSubscribed{EventID: 3, UserID: 4})
+	wg.Wait()
+
+	if got.EventID != 3 || got.UserID != 4 {
+		t.Errorf("unexpected event %+v", got)
+	}
+}
+
+func TestDeliverSkipsDeliveredSubscribers(t *testing.T) {
+	bus := NewBus()
+
+	var calls []string
+	for _, name := range []string{"first", "second", "third"} {
+		Subscribe(bus, name, func(e UserFollowed) error {
+			calls = append(calls, name)
+			if name == "third" {
+				return errors.New("third failed")
+			}
+			return nil
+		})
+	}
+
+	delivery := bus.Deliver(UserFollowed{FollowerID: 1, FollowedID: 2}, []string{"first"})
+
+	if len(calls) != 2 || calls[0] != "second" || calls[1] != "third" {
+		t.Errorf("unexpected calls %v", calls)
+	}
+	if len(delivery.Delivered) != 1 || delivery.Delivered[0] != "second" || delivery.Err == nil {
+		t.Errorf("unexpected delivery %+v", delivery)
+	}
+}
+
+func TestSubscribeRejectsRepeatedNames(t *testing.T) {
+	bus := NewBus()
+	Subscribe(bus, "notifications", func(e UserFollowed) error { return nil })
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a repeated subscriber name")
+		}
+	}()
+	Subscribe(bus, "notifications", func(e UserFollowed) error { return nil })
+}
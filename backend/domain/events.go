@@ -0,0 +1,149 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+)
+
+// Names of the domain events, they are also the names of the webhook events
+const (
+	PostCreatedEvent     = "post.created"
+	PostLikedEvent       = "post.liked"
+	CommentCreatedEvent  = "comment.created"
+	UserFollowedEvent    = "user.followed"
+	FollowRequestedEvent = "follow.requested"
+	EventCreatedEvent    = "event.created"
+	EventSubscribedEvent = "event.subscribed"
+	MessageSentEvent     = "message.sent"
+)
+
+// Event is something that already happened in the application
+type Event interface {
+	EventName() string
+	Meta() Base
+}
+
+// Base identifies an occurrence of an event. The outbox records which subscribers got every event and
+// a retry only runs the rest, a subscriber only gets an event twice if the relay stops while running it
+type Base struct {
+	ID         string    `json:"id"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+func (b Base) Meta() Base { return b }
+
+func NewBase() Base {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	return Base{
+		ID:         hex.EncodeToString(b),
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+type PostCreated struct {
+	Base
+	Post models.Post `json:"post"`
+}
+
+func (PostCreated) EventName() string { return PostCreatedEvent }
+
+type PostLiked struct {
+	Base
+	UserID int `json:"user_id"`
+	PostID int `json:"post_id"`
+}
+
+func (PostLiked) EventName() string { return PostLikedEvent }
+
+type CommentCreated struct {
+	Base
+	Comment models.Comment `json:"comment"`
+	UserID  int            `json:"user_id"`
+	PostID  int            `json:"post_id"`
+}
+
+func (CommentCreated) EventName() string { return CommentCreatedEvent }
+
+type UserFollowed struct {
+	Base
+	FollowerID int `json:"follower_id"`
+	FollowedID int `json:"followed_id"`
+	// Approved is true when the followed user accepted a follow request
+	Approved bool `json:"approved,omitempty"`
+}
+
+func (UserFollowed) EventName() string { return UserFollowedEvent }
+
+type FollowRequested struct {
+	Base
+	RequesterID int `json:"requester_id"`
+	TargetID    int `json:"target_id"`
+}
+
+func (FollowRequested) EventName() string { return FollowRequestedEvent }
+
+type EventCreated struct {
+	Base
+	Event models.EventWithUser `json:"event"`
+}
+
+func (EventCreated) EventName() string { return EventCreatedEvent }
+
+type EventSubscribed struct {
+	Base
+	EventID int `json:"event_id"`
+	UserID  int `json:"user_id"`
+}
+
+func (EventSubscribed) EventName() string { return EventSubscribedEvent }
+
+type MessageSent struct {
+	Base
+	Message models.Message `json:"message"`
+	// ReceiverOnline tells if the receiver got the message through the websocket
+	ReceiverOnline bool `json:"receiver_online"`
+	// IsRequest is true when the message went to the message requests of the receiver
+	IsRequest bool `json:"is_request"`
+}
+
+func (MessageSent) EventName() string { return MessageSentEvent }
+
+// Decode rebuilds an event stored as JSON, like the ones in the outbox
+func Decode(name string, payload []byte) (Event, error) {
+	switch name {
+	case PostCreatedEvent:
+		return decode[PostCreated](payload)
+	case PostLikedEvent:
+		return decode[PostLiked](payload)
+	case CommentCreatedEvent:
+		return decode[CommentCreated](payload)
+	case UserFollowedEvent:
+		return decode[UserFollowed](payload)
+	case FollowRequestedEvent:
+		return decode[FollowRequested](payload)
+	case EventCreatedEvent:
+		return decode[EventCreated](payload)
+	case EventSubscribedEvent:
+		return decode[EventSubscribed](payload)
+	case MessageSentEvent:
+		return decode[MessageSent](payload)
+	default:
+		return nil, fmt.Errorf("unknown event: %s", name)
+	}
+}
+
+func decode[E Event](payload []byte) (Event, error) {
+	var e E
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
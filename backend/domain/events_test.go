@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	original := UserFollowed{Base: NewBase(), FollowerID: 1, FollowedID: 2}
+
+	payload, err := json.Marshal(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := Decode(original.EventName(), payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok := decoded.(UserFollowed)
+	if !ok {
+		t.Fatalf("decoded a %T", decoded)
+	}
+	if got.Meta().ID != original.ID || !got.OccurredAt.Equal(original.OccurredAt) || got.FollowerID != 1 || got.FollowedID != 2 {
+		t.Errorf("unexpected event %+v", got)
+	}
+
+	if _, err := Decode("unknown.event", payload); err == nil {
+		t.Error("expected an error for an unknown event")
+	}
+}
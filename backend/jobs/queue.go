@@ -0,0 +1,214 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/storage"
+)
+
+// Defaults of the jobs, they can be changed per job when enqueuing
+const (
+	defaultMaxAttempts = 5
+	baseBackoff        = 10 * time.Second
+	maxBackoff         = time.Hour
+	// Running jobs locked for longer than this are considered abandoned
+	staleAfter = 15 * time.Minute
+	// The workers refresh the lock of their job this often, well before it becomes stale
+	heartbeatInterval = staleAfter / 3
+)
+
+type handler func(job *models.Job) error
+
+// Queue runs the jobs stored in Postgres with a pool of workers
+type Queue struct {
+	store     storage.Storage
+	mu        sync.RWMutex
+	handlers  map[string]handler
+	interval  time.Duration
+	heartbeat time.Duration
+}
+
+func NewQueue(store storage.Storage) *Queue {
+	return &Queue{
+		store:     store,
+		handlers:  make(map[string]handler),
+		interval:  time.Second,
+		heartbeat: heartbeatInterval,
+	}
+}
+
+// Register sets the handler of a job type, the payload is decoded to T before calling it.
+// Returning an error retries the job with backoff until it runs out of attempts
+func Register[T any](q *Queue, jobType string, h func(job *models.Job, payload T) error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.handlers[jobType] = func(job *models.Job) error {
+		var payload T
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return fmt.Errorf("could not decode the payload: %w", err)
+		}
+
+		return h(job, payload)
+	}
+}
+
+type enqueueOptions struct {
+	runAt       time.Time
+	maxAttempts int
+}
+
+type Option func(*enqueueOptions)
+
+// RunAt schedules the job for later
+func RunAt(t time.Time) Option {
+	return func(o *enqueueOptions) { o.runAt = t }
+}
+
+func MaxAttempts(n int) Option {
+	return func(o *enqueueOptions) { o.maxAttempts = n }
+}
+
+func (q *Queue) Enqueue(jobType string, payload any, opts ...Option) (int64, error) {
+	options := enqueueOptions{runAt: time.Now(), maxAttempts: defaultMaxAttempts}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	return q.store.EnqueueJob(jobType, data, options.runAt, options.maxAttempts)
+}
+
+// Start launches the workers, every worker claims and runs one job at a time
+func (q *Queue) Start(workers int) {
+	go q.rescueStaleJobs()
+
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+}
+
+func (q *Queue) work() {
+	for {
+		ran, err := q.RunNext()
+		if err != nil {
+			log.Println("jobs: could not run the next job:", err)
+		}
+
+		// Keep going while there is work, otherwise wait for new jobs
+		if !ran {
+			time.Sleep(q.interval)
+		}
+	}
+}
+
+// RunNext claims and runs a due job, it returns false when there was nothing to run
+func (q *Queue) RunNext() (bool, error) {
+	q.mu.RLock()
+	types := make([]string, 0, len(q.handlers))
+	for jobType := range q.handlers {
+		types = append(types, jobType)
+	}
+	q.mu.RUnlock()
+
+	if len(types) == 0 {
+		return false, nil
+	}
+
+	job, err := q.store.ClaimJob(types)
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	q.mu.RLock()
+	h := q.handlers[job.Type]
+	q.mu.RUnlock()
+
+	// Long jobs keep their lock fresh so they are not rescued while they still run
+	stop := q.keepAlive(job)
+	err = run(h, job)
+	stop()
+
+	if err != nil {
+		if job.Attempts >= job.MaxAttempts {
+			log.Printf("jobs: job %d (%s) is dead: %s\n", job.ID, job.Type, err)
+			return true, q.store.KillJob(job.ID, job.Attempts, err.Error())
+		}
+
+		return true, q.store.RetryJob(job.ID, job.Attempts, err.Error(), time.Now().Add(Backoff(job.Attempts)))
+	}
+
+	return true, q.store.CompleteJob(job.ID, job.Attempts)
+}
+
+// keepAlive refreshes the lock of the job until the returned function is called
+func (q *Queue) keepAlive(job *models.Job) (stop func()) {
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(q.heartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := q.store.HeartbeatJob(job.ID, job.Attempts); err != nil {
+					log.Printf("jobs: could not refresh the lock of job %d: %s\n", job.ID, err)
+				}
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// run calls the handler turning a panic into an error so the job is retried
+func run(h handler, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return h(job)
+}
+
+// Backoff returns how long to wait before the next attempt, doubling every attempt with some jitter
+func Backoff(attempts int) time.Duration {
+	wait := baseBackoff
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	wait = min(wait, maxBackoff)
+
+	// Up to 20% of jitter so the retries of many jobs do not happen at once
+	return wait + time.Duration(rand.Int64N(int64(wait)/5+1))
+}
+
+func (q *Queue) rescueStaleJobs() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		rescued, err := q.store.RescueStaleJobs(now.Add(-staleAfter))
+		if err != nil {
+			log.Println("jobs: could not rescue the stale jobs:", err)
+			continue
+		}
+		if rescued > 0 {
+			log.Printf("jobs: %d stale jobs rescued\n", rescued)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/storage"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		min      time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{4, 80 * time.Second},
+		{30, time.Hour},
+	}
+
+	for _, tt := range tests {
+		got := Backoff(tt.attempts)
+		if got < tt.min || got > tt.min+tt.min/5 {
+			t.Errorf("Backoff(%d) = %s, want between %s and %s", tt.attempts, got, tt.min, tt.min+tt.min/5)
+		}
+	}
+}
+
+// fakeStore hands out one job and records what the queue does with it
+type fakeStore struct {
+	storage.Storage
+	mu         sync.Mutex
+	job        *models.Job
+	heartbeats int
+	completed  bool
+}
+
+func (f *fakeStore) ClaimJob(types []string) (*models.Job, error) {
+	job := f.job
+	f.job = nil
+	return job, nil
+}
+
+func (f *fakeStore) HeartbeatJob(id int64, attempts int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.heartbeats++
+	return nil
+}
+
+func (f *fakeStore) CompleteJob(id int64, attempts int) error {
+	f.completed = true
+	return nil
+}
+
+func TestRunNextRefreshesTheLock(t *testing.T) {
+	store := &fakeStore{job: &models.Job{ID: 1, Type: "slow", Payload: []byte("{}"), Attempts: 1, MaxAttempts: 5}}
+	queue := NewQueue(store)
+	queue.heartbeat = 10 * time.Millisecond
+
+	Register(queue, "slow", func(job *models.Job, payload struct{}) error {
+		time.Sleep(55 * time.Millisecond)
+		return nil
+	})
+
+	ran, err := queue.RunNext()
+	if err != nil || !ran {
+		t.Fatalf("unexpected result %v %v", ran, err)
+	}
+
+	store.mu.Lock()
+	heartbeats := store.heartbeats
+	store.mu.Unlock()
+
+	if heartbeats < 2 || !store.completed {
+		t.Errorf("expected the lock to be refreshed while the job ran, got %d heartbeats, completed %v", heartbeats, store.completed)
+	}
+
+	// The heartbeat stops with the job
+	time.Sleep(30 * time.Millisecond)
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.heartbeats != heartbeats {
+		t.Errorf("the heartbeat kept running after the job, %d heartbeats", store.heartbeats)
+	}
+}
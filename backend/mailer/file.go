@@ -0,0 +1,31 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileMailer writes every email as an .eml file in Dir, it is meant for local testing
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+func (m *FileMailer) Send(msg *Message) error {
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	email, err := build(m.From, msg)
+	if err != nil {
+		return err
+	}
+
+	to := strings.NewReplacer("@", "_at_", "/", "_").Replace(msg.To)
+	name := fmt.Sprintf("%s_%s.eml", time.Now().Format("20060102T150405.000000000"), to)
+
+	return os.WriteFile(filepath.Join(m.Dir, name), email, 0o644)
+}
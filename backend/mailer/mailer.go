@@ -0,0 +1,101 @@
+package mailer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer sends the emails of the application, the implementation is chosen with the MAILER env variable
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// NewFromEnv returns the smtp mailer when MAILER=smtp and the file mailer when MAILER=file, the file mailer
+// is only for local testing so it has to be chosen explicitly
+func NewFromEnv() (Mailer, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@socialnetwork.local"
+	}
+
+	switch os.Getenv("MAILER") {
+	case "smtp":
+		if os.Getenv("SMTP_HOST") == "" {
+			return nil, errors.New("SMTP_HOST is not set")
+		}
+
+		return &SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			User:     os.Getenv("SMTP_USER"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}, nil
+	case "file":
+		dir := os.Getenv("MAILER_DIR")
+		if dir == "" {
+			dir = "mails"
+		}
+
+		return &FileMailer{Dir: dir, From: from}, nil
+	case "":
+		return nil, errors.New("MAILER is not set, use smtp or file")
+	default:
+		return nil, fmt.Errorf("unknown mailer %q, use smtp or file", os.Getenv("MAILER"))
+	}
+}
+
+// build returns the message as a multipart/alternative email with the text and html versions
+func build(from string, msg *Message) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", msg.Text},
+		{"text/html; charset=UTF-8", msg.HTML},
+	}
+
+	for _, p := range parts {
+		if p.content == "" {
+			continue
+		}
+
+		part, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {p.contentType}})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write([]byte(p.content)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	var email bytes.Buffer
+	fmt.Fprintf(&email, "From: %s\r\n", from)
+	fmt.Fprintf(&email, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&email, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&email, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&email, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&email, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+	email.Write(body.Bytes())
+
+	return email.Bytes(), nil
+}
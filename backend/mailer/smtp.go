@@ -0,0 +1,28 @@
+package mailer
+
+import (
+	"net"
+	"net/smtp"
+)
+
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(msg *Message) error {
+	email, err := build(m.From, msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.User != "" {
+		auth = smtp.PlainAuth("", m.User, m.Password, m.Host)
+	}
+
+	return smtp.SendMail(net.JoinHostPort(m.Host, m.Port), auth, m.From, []string{msg.To}, email)
+}
@@ -3,8 +3,15 @@ package main
 import (
 	"log"
 	"os"
+	"time"
 
+	"github.com/Marc-Garcia-Coronado/socialNetwork/digest"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/domain"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/jobs"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/mailer"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/outbox"
 	"github.com/Marc-Garcia-Coronado/socialNetwork/routes"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/scheduler"
 	"github.com/Marc-Garcia-Coronado/socialNetwork/storage"
 	"github.com/joho/godotenv"
 )
@@ -32,7 +39,59 @@ func main() {
 		port = "8080" // Por defecto en local
 	}
 
-	server := routes.NewAPIServer(":"+port, store)
+	// Bus de eventos del dominio, los suscriptores se registran al crear el servidor
+	bus := domain.NewBus()
+
+	// Cola de trabajos en Postgres, los manejadores se registran al crear los servicios que los usan
+	queue := jobs.NewQueue(store)
+
+	// Tareas periódicas, en cada ejecución solo las corre la instancia que consigue el lock
+	sched := scheduler.New(store)
+	registerTasks(sched, store, queue)
+
+	server := routes.NewAPIServer(":"+port, store, bus, queue, sched)
+
+	// Publicar los eventos del outbox solo cuando todos los suscriptores están registrados, si no se perderían
+	go outbox.NewRelay(store, bus).Run(time.Second)
+
+	queue.Start(4)
+	sched.Start()
 	server.Run()
 }
 
+func registerTasks(sched *scheduler.Scheduler, store *storage.PostgresStore, queue *jobs.Queue) {
+	// Borrar los mensajes temporales que ya han caducado
+	sched.MustRegister("purge-expired-messages", "* * * * *", func(now time.Time) error {
+		deleted, err := store.PurgeExpiredMessages(now)
+		if deleted > 0 {
+			log.Printf("Mensajes caducados borrados: %d\n", deleted)
+		}
+		return err
+	})
+
+	// Encolar un email por cada resumen de actividad pendiente, la cola reintenta los que fallan.
+	// Sin un mailer configurado los resúmenes se desactivan en lugar de escribirse en disco
+	m, err := mailer.NewFromEnv()
+	if err != nil {
+		log.Println("ATENCIÓN: resúmenes de actividad por email desactivados:", err)
+	} else {
+		digests := digest.NewJob(store, m, queue)
+		sched.MustRegister("send-digests", "0 * * * *", func(now time.Time) error {
+			queued, err := digests.Run(now)
+			if queued > 0 {
+				log.Printf("Resúmenes de actividad encolados: %d\n", queued)
+			}
+			return err
+		})
+	}
+
+	// Limpiar los eventos ya publicados del outbox y los trabajos terminados
+	sched.MustRegister("purge-outbox", "0 3 * * *", func(now time.Time) error {
+		_, err := store.PurgePublishedOutboxEvents(now.AddDate(0, 0, -7))
+		return err
+	})
+	sched.MustRegister("purge-finished-jobs", "30 3 * * *", func(now time.Time) error {
+		_, err := store.PurgeFinishedJobs(now.AddDate(0, 0, -7))
+		return err
+	})
+}
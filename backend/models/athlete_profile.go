@@ -0,0 +1,201 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Skill levels of a practised sport
+const (
+	SkillLevelBeginner     = "beginner"
+	SkillLevelIntermediate = "intermediate"
+	SkillLevelAdvanced     = "advanced"
+	SkillLevelProfessional = "professional"
+)
+
+var SkillLevels = []string{SkillLevelBeginner, SkillLevelIntermediate, SkillLevelAdvanced, SkillLevelProfessional}
+
+// Moments of the day the user likes to train
+var TrainingTimes = []string{"early_morning", "morning", "midday", "afternoon", "evening", "night"}
+
+// Who can see a field of the athlete profile
+const (
+	ProfileVisibilityPublic    = "public"
+	ProfileVisibilityFollowers = "followers"
+	ProfileVisibilityPrivate   = "private"
+)
+
+// Fields of the athlete profile with their own visibility
+const (
+	ProfileFieldSports        = "sports"
+	ProfileFieldHomeCity      = "home_city"
+	ProfileFieldTrainingTimes = "training_times"
+	ProfileFieldHeight        = "height_cm"
+	ProfileFieldWeight        = "weight_kg"
+	ProfileFieldLinks         = "links"
+)
+
+const (
+	maxProfileSports = 20
+	maxProfileLinks  = 5
+)
+
+// ProfileVisibility tells for every field of the athlete profile who can see it
+type ProfileVisibility map[string]string
+
+// DefaultProfileVisibility is used for every field the user has not changed, the body measures are private
+func DefaultProfileVisibility() ProfileVisibility {
+	return ProfileVisibility{
+		ProfileFieldSports:        ProfileVisibilityPublic,
+		ProfileFieldHomeCity:      ProfileVisibilityPublic,
+		ProfileFieldTrainingTimes: ProfileVisibilityPublic,
+		ProfileFieldHeight:        ProfileVisibilityPrivate,
+		ProfileFieldWeight:        ProfileVisibilityPrivate,
+		ProfileFieldLinks:         ProfileVisibilityPublic,
+	}
+}
+
+type ProfileSport struct {
+	Sport      string `json:"sport"`
+	SkillLevel string `json:"skill_level"`
+}
+
+type ProfileLink struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+// AthleteProfile is the sports information of a user, the fields hidden to the viewer are left empty
+type AthleteProfile struct {
+	Sports        []ProfileSport `json:"sports,omitempty"`
+	HomeCity      *string        `json:"home_city,omitempty"`
+	TrainingTimes []string       `json:"training_times,omitempty"`
+	HeightCm      *int           `json:"height_cm,omitempty"`
+	WeightKg      *float64       `json:"weight_kg,omitempty"`
+	Links         []ProfileLink  `json:"links,omitempty"`
+	// Visibility is only returned to the owner of the profile
+	Visibility ProfileVisibility `json:"visibility,omitempty"`
+}
+
+// VisibleTo returns the fields of the profile that a user who is not the owner can see
+func (p *AthleteProfile) VisibleTo(isFollower bool) *AthleteProfile {
+	visible := func(field string) bool {
+		switch p.Visibility[field] {
+		case ProfileVisibilityPublic:
+			return true
+		case ProfileVisibilityFollowers:
+			return isFollower
+		default:
+			return false
+		}
+	}
+
+	profile := new(AthleteProfile)
+	if visible(ProfileFieldSports) {
+		profile.Sports = p.Sports
+	}
+	if visible(ProfileFieldHomeCity) {
+		profile.HomeCity = p.HomeCity
+	}
+	if visible(ProfileFieldTrainingTimes) {
+		profile.TrainingTimes = p.TrainingTimes
+	}
+	if visible(ProfileFieldHeight) {
+		profile.HeightCm = p.HeightCm
+	}
+	if visible(ProfileFieldWeight) {
+		profile.WeightKg = p.WeightKg
+	}
+	if visible(ProfileFieldLinks) {
+		profile.Links = p.Links
+	}
+
+	return profile
+}
+
+// AthleteProfileReq replaces the whole athlete profile, the visibility only changes the given fields
+type AthleteProfileReq struct {
+	Sports        []ProfileSport    `json:"sports"`
+	HomeCity      *string           `json:"home_city"`
+	TrainingTimes []string          `json:"training_times"`
+	HeightCm      *int              `json:"height_cm"`
+	WeightKg      *float64          `json:"weight_kg"`
+	Links         []ProfileLink     `json:"links"`
+	Visibility    ProfileVisibility `json:"visibility"`
+}
+
+// Normalize trims the values and checks them, sports are stored in lower case
+func (r *AthleteProfileReq) Normalize() error {
+	if len(r.Sports) > maxProfileSports {
+		return fmt.Errorf("a profile can have at most %d sports", maxProfileSports)
+	}
+	seen := make(map[string]bool, len(r.Sports))
+	for i := range r.Sports {
+		sport := strings.ToLower(strings.TrimSpace(r.Sports[i].Sport))
+		if sport == "" || len(sport) > 50 {
+			return fmt.Errorf("invalid sport %q", r.Sports[i].Sport)
+		}
+		if seen[sport] {
+			return fmt.Errorf("the sport %q is repeated", sport)
+		}
+		seen[sport] = true
+		if !slices.Contains(SkillLevels, r.Sports[i].SkillLevel) {
+			return fmt.Errorf("invalid skill level %q", r.Sports[i].SkillLevel)
+		}
+		r.Sports[i].Sport = sport
+	}
+
+	if r.HomeCity != nil {
+		city := strings.TrimSpace(*r.HomeCity)
+		if len(city) > 100 {
+			return fmt.Errorf("the home city is too long")
+		}
+		r.HomeCity = &city
+		if city == "" {
+			r.HomeCity = nil
+		}
+	}
+
+	for _, trainingTime := range r.TrainingTimes {
+		if !slices.Contains(TrainingTimes, trainingTime) {
+			return fmt.Errorf("invalid training time %q", trainingTime)
+		}
+	}
+
+	if r.HeightCm != nil && (*r.HeightCm < 50 || *r.HeightCm > 260) {
+		return fmt.Errorf("the height must be between 50 and 260 cm")
+	}
+	if r.WeightKg != nil && (*r.WeightKg < 20 || *r.WeightKg > 400) {
+		return fmt.Errorf("the weight must be between 20 and 400 kg")
+	}
+
+	if len(r.Links) > maxProfileLinks {
+		return fmt.Errorf("a profile can have at most %d links", maxProfileLinks)
+	}
+	for i := range r.Links {
+		r.Links[i].Label = strings.TrimSpace(r.Links[i].Label)
+		if r.Links[i].Label == "" || len(r.Links[i].Label) > 50 {
+			return fmt.Errorf("invalid link label %q", r.Links[i].Label)
+		}
+		u, err := url.Parse(r.Links[i].URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid link url %q", r.Links[i].URL)
+		}
+	}
+
+	defaults := DefaultProfileVisibility()
+	for field, visibility := range r.Visibility {
+		if _, ok := defaults[field]; !ok {
+			return fmt.Errorf("invalid profile field %q", field)
+		}
+		switch visibility {
+		case ProfileVisibilityPublic, ProfileVisibilityFollowers, ProfileVisibilityPrivate:
+		default:
+			return fmt.Errorf("invalid visibility %q", visibility)
+		}
+	}
+
+	return nil
+}
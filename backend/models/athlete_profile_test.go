@@ -0,0 +1,54 @@
+package models
+
+import "testing"
+
+func TestAthleteProfileReqNormalize(t *testing.T) {
+	city := "  Barcelona "
+	req := AthleteProfileReq{
+		Sports:   []ProfileSport{{Sport: " Running", SkillLevel: SkillLevelAdvanced}},
+		HomeCity: &city,
+		Links:    []ProfileLink{{Label: "Strava", URL: "https://strava.com/athletes/1"}},
+	}
+	if err := req.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	if req.Sports[0].Sport != "running" || *req.HomeCity != "Barcelona" {
+		t.Errorf("unexpected normalized profile %+v", req)
+	}
+
+	invalid := []AthleteProfileReq{
+		{Sports: []ProfileSport{{Sport: "running", SkillLevel: "god"}}},
+		{Sports: []ProfileSport{{Sport: "running", SkillLevel: SkillLevelBeginner}, {Sport: "Running", SkillLevel: SkillLevelAdvanced}}},
+		{TrainingTimes: []string{"midnight"}},
+		{Links: []ProfileLink{{Label: "web", URL: "javascript:alert(1)"}}},
+		{Visibility: ProfileVisibility{"email": ProfileVisibilityPublic}},
+		{Visibility: ProfileVisibility{ProfileFieldHeight: "friends"}},
+	}
+	for _, req := range invalid {
+		if err := req.Normalize(); err == nil {
+			t.Errorf("expected an error for %+v", req)
+		}
+	}
+}
+
+func TestAthleteProfileVisibleTo(t *testing.T) {
+	city := "Barcelona"
+	height := 180
+	profile := &AthleteProfile{
+		Sports:     []ProfileSport{{Sport: "running", SkillLevel: SkillLevelAdvanced}},
+		HomeCity:   &city,
+		HeightCm:   &height,
+		Visibility: DefaultProfileVisibility(),
+	}
+	profile.Visibility[ProfileFieldHomeCity] = ProfileVisibilityFollowers
+
+	stranger := profile.VisibleTo(false)
+	if len(stranger.Sports) != 1 || stranger.HomeCity != nil || stranger.HeightCm != nil || stranger.Visibility != nil {
+		t.Errorf("unexpected profile for a stranger %+v", stranger)
+	}
+
+	follower := profile.VisibleTo(true)
+	if follower.HomeCity == nil || follower.HeightCm != nil {
+		t.Errorf("unexpected profile for a follower %+v", follower)
+	}
+}
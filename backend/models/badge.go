@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Rules that award a badge automatically when the count of the activity reaches the threshold
+const (
+	BadgeRulePosts           = "posts"
+	BadgeRuleEventsOrganised = "events_organised"
+	BadgeRuleEventsAttended  = "events_attended"
+	BadgeRuleLikesReceived   = "likes_received"
+	BadgeRuleFollowers       = "followers"
+)
+
+var BadgeRules = []string{BadgeRulePosts, BadgeRuleEventsOrganised, BadgeRuleEventsAttended, BadgeRuleLikesReceived, BadgeRuleFollowers}
+
+// BadgeReq defines a badge, the badges without a rule are only awarded by the admins
+type BadgeReq struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Icon        *string `json:"icon"`
+	Rule        *string `json:"rule"`
+	Threshold   *int    `json:"threshold"`
+}
+
+type Badge struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Icon        *string   `json:"icon"`
+	Rule        *string   `json:"rule"`
+	Threshold   *int      `json:"threshold"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// UserBadge is a badge awarded to a user, AwardedBy is the admin that awarded it by hand
+type UserBadge struct {
+	Badge
+	AwardedAt time.Time `json:"awarded_at"`
+	AwardedBy *int      `json:"awarded_by,omitempty"`
+}
@@ -7,11 +7,11 @@ type CommentReq struct {
 }
 
 type Comment struct {
-	ID        int    `json:"id"`
-	Body      string `json:"body"`
-	User      User   `json:"user"`
-	Post      Post   `json:"post"`
-	CreatedAt string `json:"created_at"`
+	ID        int        `json:"id"`
+	Body      string     `json:"body"`
+	User      PublicUser `json:"user"`
+	Post      Post       `json:"post"`
+	CreatedAt string     `json:"created_at"`
 }
 
 type CommentsWithCountRes struct {
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// How often the activity digest is emailed
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+type DigestSettingsReq struct {
+	Frequency string `json:"frequency"`
+}
+
+type DigestSettings struct {
+	Frequency  string  `json:"frequency"`
+	LastSentAt *string `json:"last_sent_at"`
+}
+
+// DigestRecipient is a user whose digest has to be sent, Since is the start of the summarized period
+type DigestRecipient struct {
+	User      User
+	Frequency string
+	Since     time.Time
+}
+
+type Digest struct {
+	User           User
+	Frequency      string
+	Since          time.Time
+	NewFollowers   []PublicUser
+	TopPosts       []Post
+	UpcomingEvents []SubscribedEvent
+	UnreadMessages int
+}
+
+// IsEmpty tells if there is nothing worth sending in the digest
+func (d *Digest) IsEmpty() bool {
+	return len(d.NewFollowers) == 0 && len(d.TopPosts) == 0 && len(d.UpcomingEvents) == 0 && d.UnreadMessages == 0
+}
@@ -27,7 +27,7 @@ type EventWithUser struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Location    string `json:"location"`
-	Creator     User   `json:"creator"`
+	Creator     PublicUser `json:"creator"`
 	Topic       Topic  `json:"topic"`
 	Date        string `json:"date"`
 	CreatedAt   string `json:"created_at"`
@@ -39,7 +39,7 @@ type SubscribedEvent struct {
 	Name         string `json:"name"`
 	Description  string `json:"description"`
 	Location     string `json:"location"`
-	Creator      User   `json:"creator"`
+	Creator      PublicUser `json:"creator"`
 	Topic        Topic  `json:"topic"`
 	Date         string `json:"date"`
 	CreatedAt    string `json:"created_at"`
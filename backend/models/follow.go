@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Result of following a user, private accounts have to approve the follow request first
+const (
+	FollowStatusFollowing = "following"
+	FollowStatusRequested = "requested"
+)
+
+type AccountPrivacyReq struct {
+	IsPrivate bool `json:"is_private"`
+}
+
+// FollowRequest is a pending follow to a private account, User is the other side of the request
+type FollowRequest struct {
+	ID        int        `json:"id"`
+	User      PublicUser `json:"user"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type FollowRequestsWithPagination struct {
+	Requests   []FollowRequest `json:"requests"`
+	Pagination Pagination      `json:"pagination"`
+}
+
+// FollowSuggestion is a user the user may want to follow with the reasons it was suggested
+type FollowSuggestion struct {
+	User          PublicUser `json:"user"`
+	MutualFollows int        `json:"mutual_follows"`
+	SharedTopics  int        `json:"shared_topics"`
+	SharedEvents  int        `json:"shared_events"`
+	RecentPosts   int        `json:"recent_posts"`
+}
+
+// Relationship is how the user of the JWT is related with another user, KnownFollowers are some of the users the
+// viewer follows that also follow the user
+type Relationship struct {
+	UserID              int          `json:"user_id"`
+	Following           bool         `json:"following"`
+	FollowedBy          bool         `json:"followed_by"`
+	Mutual              bool         `json:"mutual"`
+	Requested           bool         `json:"requested"`
+	RequestedBy         bool         `json:"requested_by"`
+	Blocked             bool         `json:"blocked"`
+	Muted               bool         `json:"muted"`
+	KnownFollowers      []PublicUser `json:"known_followers"`
+	KnownFollowersCount int          `json:"known_followers_count"`
+	// BlockedBy is never returned, the users blocked by the other user must not know it
+	BlockedBy bool `json:"-"`
+}
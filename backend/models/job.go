@@ -0,0 +1,29 @@
+package models
+
+import "encoding/json"
+
+// Status of the jobs of the queue, dead jobs ran out of attempts and wait for an admin
+const (
+	JobPending = "pending"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobDead    = "dead"
+)
+
+type Job struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       string          `json:"run_at"`
+	LastError   *string         `json:"last_error"`
+	CreatedAt   string          `json:"created_at"`
+	UpdatedAt   string          `json:"updated_at"`
+}
+
+type JobsWithPagination struct {
+	Jobs       []Job      `json:"jobs"`
+	Pagination Pagination `json:"pagination"`
+}
@@ -0,0 +1,52 @@
+package models
+
+// Public keys are sent and stored base64 encoded, the server never sees private keys or plaintext
+
+type SignedPreKey struct {
+	KeyID     int    `json:"key_id"`
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+}
+
+type OneTimePreKey struct {
+	KeyID     int    `json:"key_id"`
+	PublicKey string `json:"public_key"`
+}
+
+type PublishKeysReq struct {
+	IdentityKey    string          `json:"identity_key"`
+	RegistrationID int             `json:"registration_id"`
+	SignedPreKey   SignedPreKey    `json:"signed_pre_key"`
+	OneTimePreKeys []OneTimePreKey `json:"one_time_pre_keys"`
+}
+
+type UploadPreKeysReq struct {
+	OneTimePreKeys []OneTimePreKey `json:"one_time_pre_keys"`
+}
+
+// KeyBundle is what another user needs to start an encrypted session with a device,
+// the one time pre key is consumed when the bundle is fetched and it can be missing
+type KeyBundle struct {
+	UserID         int            `json:"user_id"`
+	DeviceID       string         `json:"device_id"`
+	RegistrationID int            `json:"registration_id"`
+	IdentityKey    string         `json:"identity_key"`
+	SignedPreKey   SignedPreKey   `json:"signed_pre_key"`
+	OneTimePreKey  *OneTimePreKey `json:"one_time_pre_key"`
+}
+
+type Device struct {
+	DeviceID          string `json:"device_id"`
+	RegistrationID    int    `json:"registration_id"`
+	IdentityKey       string `json:"identity_key"`
+	OneTimePreKeysLen int    `json:"one_time_pre_keys_count"`
+	CreatedAt         string `json:"created_at"`
+	UpdatedAt         string `json:"updated_at"`
+}
+
+// DeviceCiphertext is the opaque payload of an encrypted message for one device
+type DeviceCiphertext struct {
+	UserID     int    `json:"user_id"`
+	DeviceID   string `json:"device_id"`
+	Ciphertext string `json:"ciphertext"`
+}
@@ -7,17 +7,17 @@ type LikeReq struct {
 }
 
 type LikePost struct {
-	ID        int    `json:"id"`
-	User      User   `json:"user"`
-	Post      Post   `json:"post"`
-	CreatedAt string `json:"created_at"`
+	ID        int        `json:"id"`
+	User      PublicUser `json:"user"`
+	Post      Post       `json:"post"`
+	CreatedAt string     `json:"created_at"`
 }
 
 type LikeComment struct {
-	ID        int     `json:"id"`
-	User      User    `json:"user"`
-	Comment   Comment `json:"comment"`
-	CreatedAt string  `json:"created_at"`
+	ID        int        `json:"id"`
+	User      PublicUser `json:"user"`
+	Comment   Comment    `json:"comment"`
+	CreatedAt string     `json:"created_at"`
 }
 
 type LikesWithPagination[T any] struct {
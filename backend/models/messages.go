@@ -7,13 +7,127 @@ type MessageReq struct {
 	Content    string `json:"content"`
 	CreatedAt  string `json:"created_at"`
 	IsRead     bool   `json:"is_read"`
+	IsSystem   bool   `json:"is_system"`
+	// Mode is plain or e2e, encrypted messages carry one ciphertext per device instead of content
+	Mode        string             `json:"mode"`
+	Ciphertexts []DeviceCiphertext `json:"ciphertexts"`
+}
+
+const (
+	MessageModePlain = "plain"
+	MessageModeE2E   = "e2e"
+)
+
+// WSMessageReq is the frame a client sends through the websocket to write a message
+type WSMessageReq struct {
+	To          string             `json:"to"`
+	Content     string             `json:"content"`
+	Mode        string             `json:"mode"`
+	Ciphertexts []DeviceCiphertext `json:"ciphertexts"`
 }
 
 type Message struct {
-	ID        int    `json:"id"`
-	Sender    User   `json:"sender"`
-	Receiver  User   `json:"reciever"`
-	Content   string `json:"content"`
-	CreatedAt string `json:"created_at"`
-	IsRead    bool   `json:"is_read"`
+	ID        int               `json:"id"`
+	Sender    PublicUser        `json:"sender"`
+	Receiver  PublicUser        `json:"reciever"`
+	Content   string            `json:"content"`
+	CreatedAt string            `json:"created_at"`
+	IsRead    bool              `json:"is_read"`
+	IsSystem  bool              `json:"is_system"`
+	ExpiresAt *string           `json:"expires_at,omitempty"`
+	Reactions []MessageReaction `json:"reactions,omitempty"`
+	Mode      string            `json:"mode"`
+	// Ciphertext is filled with the payload of the device that asks for the conversation
+	Ciphertext  *string            `json:"ciphertext,omitempty"`
+	Ciphertexts []DeviceCiphertext `json:"ciphertexts,omitempty"`
+}
+
+// MessageReaction groups the reactions of a message by emoji
+type MessageReaction struct {
+	Emoji   string `json:"emoji"`
+	Count   int    `json:"count"`
+	UserIDs []int  `json:"user_ids"`
+}
+
+type MessageReactionReq struct {
+	Emoji string `json:"emoji"`
+}
+
+type MessageReactionEvent struct {
+	MessageID int    `json:"message_id"`
+	UserID    int    `json:"user_id"`
+	Emoji     string `json:"emoji"`
+}
+
+const (
+	MessagePrivacyEveryone  = "everyone"
+	MessagePrivacyFollowers = "followers"
+	MessagePrivacyNobody    = "nobody"
+)
+
+// Result of checking if a user can send a message to another one
+const (
+	MessageDeliveryDirect  = "direct"
+	MessageDeliveryRequest = "request"
+	MessageDeliveryDenied  = "denied"
+)
+
+type MessagePrivacyReq struct {
+	MessagePrivacy string `json:"message_privacy"`
+}
+
+type MessageRequest struct {
+	ID            int        `json:"id"`
+	Sender        PublicUser `json:"sender"`
+	Status        string     `json:"status"`
+	LastMessage   string     `json:"last_message"`
+	MessagesCount int        `json:"messages_count"`
+	CreatedAt     string     `json:"created_at"`
+	UpdatedAt     string     `json:"updated_at"`
+}
+
+type WSEvent struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// Filters for the user conversations list
+const (
+	ConversationFilterInbox    = "inbox"
+	ConversationFilterArchived = "archived"
+	ConversationFilterPinned   = "pinned"
+	ConversationFilterMuted    = "muted"
+	ConversationFilterAll      = "all"
+)
+
+// Conversation keeps the other user fields at the top level so the list can be used as a list of users
+type Conversation struct {
+	PublicUser
+	Pinned        bool    `json:"pinned"`
+	Archived      bool    `json:"archived"`
+	Muted         bool    `json:"muted"`
+	MutedUntil    *string `json:"muted_until"`
+	LastMessageAt string  `json:"last_message_at"`
+}
+
+type MuteConversationReq struct {
+	Until string `json:"until"`
+}
+
+// Allowed timers for disappearing messages, the value is the retention in seconds
+var MessageRetentions = map[string]int{
+	"off": 0,
+	"24h": 24 * 60 * 60,
+	"7d":  7 * 24 * 60 * 60,
+	"90d": 90 * 24 * 60 * 60,
+}
+
+type ConversationRetentionReq struct {
+	Retention string `json:"retention"`
+}
+
+type ConversationRetention struct {
+	RetentionSeconds int     `json:"retention_seconds"`
+	UpdatedBy        *int    `json:"updated_by"`
+	UpdatedAt        *string `json:"updated_at"`
 }
@@ -0,0 +1,72 @@
+package models
+
+// Activity types that generate a notification
+const (
+	NotificationPostLiked       = "post_liked"
+	NotificationPostCommented   = "post_commented"
+	NotificationUserFollowed    = "user_followed"
+	NotificationFollowRequested = "follow_requested"
+	NotificationFollowAccepted  = "follow_accepted"
+	NotificationEventSubscribed = "event_subscribed"
+	NotificationEventReminder   = "event_reminder"
+	NotificationMessage         = "message"
+)
+
+// Channels where a notification can be delivered
+const (
+	NotificationChannelInApp = "in_app"
+	NotificationChannelEmail = "email"
+	NotificationChannelPush  = "push"
+)
+
+// NotificationPreferences tells for every activity type and channel if the user wants to be notified
+type NotificationPreferences map[string]map[string]bool
+
+// DefaultNotificationPreferences are used for every type and channel the user has not changed. Messages have no
+// in app channel because the conversation already shows them, and only the types in the digest have an email channel
+func DefaultNotificationPreferences() NotificationPreferences {
+	return NotificationPreferences{
+		NotificationPostLiked:       {NotificationChannelInApp: true, NotificationChannelPush: false},
+		NotificationPostCommented:   {NotificationChannelInApp: true, NotificationChannelPush: true},
+		NotificationUserFollowed:    {NotificationChannelInApp: true, NotificationChannelEmail: true, NotificationChannelPush: true},
+		NotificationFollowRequested: {NotificationChannelInApp: true, NotificationChannelPush: true},
+		NotificationFollowAccepted:  {NotificationChannelInApp: true, NotificationChannelPush: true},
+		NotificationEventSubscribed: {NotificationChannelInApp: true, NotificationChannelPush: true},
+		NotificationEventReminder:   {NotificationChannelInApp: true, NotificationChannelEmail: true, NotificationChannelPush: true},
+		NotificationMessage:         {NotificationChannelEmail: false, NotificationChannelPush: true},
+	}
+}
+
+type NotificationReq struct {
+	UserID    int
+	ActorID   int
+	Type      string
+	PostID    *int
+	CommentID *int
+	EventID   *int
+}
+
+type Notification struct {
+	ID          int          `json:"id"`
+	Type        string       `json:"type"`
+	Text        string       `json:"text"`
+	Actors      []PublicUser `json:"actors"` // The latest actors, the total is in ActorsCount
+	ActorsCount int          `json:"actors_count"`
+	PostID      *int         `json:"post_id,omitempty"`
+	CommentID   *int         `json:"comment_id,omitempty"`
+	EventID     *int         `json:"event_id,omitempty"`
+	IsRead      bool         `json:"is_read"`
+	CreatedAt   string       `json:"created_at"`
+	UpdatedAt   string       `json:"updated_at"`
+}
+
+// NotificationEvent is the payload of the notification frames of the websocket
+type NotificationEvent struct {
+	Notification *Notification `json:"notification,omitempty"`
+	UnreadCount  int           `json:"unread_count"`
+}
+
+type NotificationsWithPagination struct {
+	Notifications []Notification `json:"notifications"`
+	Pagination    Pagination     `json:"pagination"`
+}
@@ -0,0 +1,10 @@
+package models
+
+// OutboxEvent is a domain event waiting in the outbox, DeliveredTo are the subscribers that already got it
+type OutboxEvent struct {
+	ID          int64
+	Name        string
+	Payload     []byte
+	Attempts    int
+	DeliveredTo []string
+}
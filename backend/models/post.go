@@ -2,6 +2,9 @@ package models
 
 import "database/sql"
 
+// MaxPinnedPosts is how many posts a user can pin on the profile
+const MaxPinnedPosts = 3
+
 type PostReq struct {
 	Picture string `json:"picture"`
 	Title   string `json:"title"`
@@ -13,9 +16,11 @@ type Post struct {
 	ID        int            `json:"id"`
 	Picture   sql.NullString `json:"picture"`
 	Title     string         `json:"title"`
-	User      User           `json:"user"`
+	User      PublicUser     `json:"user"`
 	Topic     Topic          `json:"topic"`
 	CreatedAt string         `json:"created_at"`
+	// Pinned is only set on the posts of a profile
+	Pinned bool `json:"pinned"`
 }
 
 type PostsWithPagination struct {
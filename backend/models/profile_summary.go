@@ -0,0 +1,17 @@
+package models
+
+type ProfileCounts struct {
+	Followers        int `json:"followers"`
+	Follows          int `json:"follows"`
+	Posts            int `json:"posts"`
+	Events           int `json:"events"`
+	SubscribedEvents int `json:"subscribed_events"`
+	Topics           int `json:"topics"`
+}
+
+// ProfileSummary has everything the profile header needs, Relationship is empty on the own profile
+type ProfileSummary struct {
+	UserID       int           `json:"user_id"`
+	Counts       ProfileCounts `json:"counts"`
+	Relationship *Relationship `json:"relationship,omitempty"`
+}
@@ -0,0 +1,32 @@
+package models
+
+type PushSubscriptionKeys struct {
+	P256dh string `json:"p256dh"`
+	Auth   string `json:"auth"`
+}
+
+// PushSubscriptionReq is the PushSubscription object of the browser serialized with toJSON()
+type PushSubscriptionReq struct {
+	Endpoint string               `json:"endpoint"`
+	Keys     PushSubscriptionKeys `json:"keys"`
+}
+
+type PushSubscription struct {
+	ID        int                  `json:"id"`
+	UserID    int                  `json:"-"`
+	Endpoint  string               `json:"endpoint"`
+	Keys      PushSubscriptionKeys `json:"keys"`
+	UserAgent string               `json:"user_agent"`
+	CreatedAt string               `json:"created_at"`
+}
+
+// PushPayload is the JSON received by the service worker of the frontend
+type PushPayload struct {
+	Type           string `json:"type"`
+	Title          string `json:"title"`
+	Body           string `json:"body"`
+	NotificationID *int   `json:"notification_id,omitempty"`
+	PostID         *int   `json:"post_id,omitempty"`
+	EventID        *int   `json:"event_id,omitempty"`
+	SenderID       *int   `json:"sender_id,omitempty"`
+}
@@ -0,0 +1,22 @@
+package models
+
+// ScheduledTask is a periodic task of the scheduler with the result of its last run
+type ScheduledTask struct {
+	Name             string  `json:"name"`
+	Schedule         string  `json:"schedule"`
+	NextRunAt        string  `json:"next_run_at"`
+	LastScheduledFor *string `json:"last_scheduled_for"`
+	LastStartedAt    *string `json:"last_started_at"`
+	LastFinishedAt   *string `json:"last_finished_at"`
+	LastStatus       *string `json:"last_status"`
+	LastError        *string `json:"last_error"`
+	LastDurationMs   *int64  `json:"last_duration_ms"`
+	RunsCount        int     `json:"runs_count"`
+}
+
+// EventReminder is a subscription to an event that starts soon
+type EventReminder struct {
+	UserID    int
+	EventID   int
+	CreatorID int
+}
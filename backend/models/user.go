@@ -30,11 +30,15 @@ type User struct {
 	ProfilePicture *string   `json:"profile_picture,omitempty"`
 	Bio            *string   `json:"bio,omitempty"`
 	IsActive       bool      `json:"is_active"`
+	IsPrivate      bool      `json:"is_private"`
 	Role           string    `json:"role"`
+	// AthleteProfile and Badges are only loaded on the profile reads
+	AthleteProfile *AthleteProfile `json:"athlete_profile,omitempty"`
+	Badges         []UserBadge     `json:"badges,omitempty"`
 }
 
 type UserWithPagination struct {
-	Users      []User     `json:"users"`
+	Users      []PublicUser `json:"users"`
 	Pagination Pagination `json:"pagination"`
 }
 
@@ -56,3 +60,30 @@ func NewUser(userName, fullName, email, password, bio, profile_picture string) *
 		ProfilePicture: &profile_picture,
 	}
 }
+
+// PublicUser is the profile of a user as the rest of users see it, it never has the email or other private fields.
+// It is the type of the users nested in posts, comments, likes, messages, events and lists of users
+type PublicUser struct {
+	ID             int     `json:"id"`
+	UserName       string  `json:"user_name"`
+	FullName       string  `json:"full_name"`
+	ProfilePicture *string `json:"profile_picture,omitempty"`
+	Bio            *string `json:"bio,omitempty"`
+	IsActive       bool    `json:"is_active"`
+	Role           string  `json:"role"`
+	// AthleteProfile only has the fields the viewer can see, it is only loaded on the profile reads
+	AthleteProfile *AthleteProfile `json:"athlete_profile,omitempty"`
+	Badges         []UserBadge     `json:"badges,omitempty"`
+}
+
+func (u *User) Public() PublicUser {
+	return PublicUser{
+		ID:             u.ID,
+		UserName:       u.UserName,
+		FullName:       u.FullName,
+		ProfilePicture: u.ProfilePicture,
+		Bio:            u.Bio,
+		IsActive:       u.IsActive,
+		Role:           u.Role,
+	}
+}
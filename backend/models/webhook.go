@@ -0,0 +1,76 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Events that can be delivered to a webhook
+const (
+	WebhookPostCreated     = "post.created"
+	WebhookEventCreated    = "event.created"
+	WebhookEventSubscribed = "event.subscribed"
+	WebhookUserFollowed    = "user.followed"
+)
+
+var WebhookEvents = []string{WebhookPostCreated, WebhookEventCreated, WebhookEventSubscribed, WebhookUserFollowed}
+
+// Events that can be filtered by topic
+var WebhookTopicEvents = []string{WebhookPostCreated, WebhookEventCreated}
+
+// WebhookReq subscribes a url to events, with a topic the post.created and event.created events are the ones
+// of that topic published by any user instead of the ones of the owner
+type WebhookReq struct {
+	URL     string   `json:"url"`
+	Events  []string `json:"events"`
+	TopicID *int     `json:"topic_id"`
+}
+
+type Webhook struct {
+	ID      int      `json:"id"`
+	UserID  *int     `json:"user_id"`
+	URL     string   `json:"url"`
+	Events  []string `json:"events"`
+	TopicID *int     `json:"topic_id"`
+	// Secret is only returned when the webhook is created
+	Secret    string `json:"secret,omitempty"`
+	IsActive  bool   `json:"is_active"`
+	CreatedAt string `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID          int             `json:"id"`
+	WebhookID   int             `json:"webhook_id"`
+	DeliveryID  string          `json:"delivery_id"`
+	Event       string          `json:"event"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempt     int             `json:"attempt"`
+	StatusCode  *int            `json:"status_code"`
+	Error       *string         `json:"error"`
+	Success     bool            `json:"success"`
+	DurationMs  int64           `json:"duration_ms"`
+	DeliveredAt string          `json:"delivered_at"`
+}
+
+type WebhookDeliveriesWithPagination struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	Pagination Pagination        `json:"pagination"`
+}
+
+// WebhookPayload is the body posted to the webhook url
+type WebhookPayload struct {
+	ID        string    `json:"id"`
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+type EventSubscribedData struct {
+	EventID int `json:"event_id"`
+	UserID  int `json:"user_id"`
+}
+
+type UserFollowedData struct {
+	FollowerID int `json:"follower_id"`
+	FollowedID int `json:"followed_id"`
+}
@@ -0,0 +1,343 @@
+package notifications
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/domain"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/storage"
+)
+
+// Publisher delivers the notifications as soon as they are created
+type Publisher interface {
+	PublishNotification(userID int, notification *models.Notification, unreadCount int)
+	PublishUnreadCount(userID int, unreadCount int)
+}
+
+// Pusher delivers push notifications to the devices of the user
+type Pusher interface {
+	Push(userID int, payload *models.PushPayload)
+}
+
+// Service records the activity of the users as notifications for the affected user
+type Service struct {
+	store      storage.Storage
+	publishers []Publisher
+	pusher     Pusher
+}
+
+func NewService(store storage.Storage) *Service {
+	return &Service{
+		store: store,
+	}
+}
+
+func (s *Service) AddPublisher(p Publisher) {
+	s.publishers = append(s.publishers, p)
+}
+
+func (s *Service) SetPusher(p Pusher) {
+	s.pusher = p
+}
+
+// Name of the service among the subscribers of the domain events
+const subscriberName = "notifications"
+
+// Subscribe notifies the affected users of the domain events, a notification that could not be stored
+// fails the event so the outbox relays it again, the grouping of the notifications absorbs the repetition
+func (s *Service) Subscribe(bus *domain.Bus) {
+	domain.Subscribe(bus, subscriberName, func(e domain.PostLiked) error { return s.PostLiked(e.UserID, e.PostID) })
+	domain.Subscribe(bus, subscriberName, func(e domain.CommentCreated) error { return s.PostCommented(e.UserID, e.PostID, e.Comment.ID) })
+	domain.Subscribe(bus, subscriberName, func(e domain.UserFollowed) error {
+		// The followed user approved the request, the one waiting for the answer is the follower
+		if e.Approved {
+			return s.FollowAccepted(e.FollowedID, e.FollowerID)
+		}
+		return s.UserFollowed(e.FollowerID, e.FollowedID)
+	})
+	domain.Subscribe(bus, subscriberName, func(e domain.FollowRequested) error { return s.FollowRequested(e.RequesterID, e.TargetID) })
+	domain.Subscribe(bus, subscriberName, func(e domain.EventSubscribed) error { return s.EventSubscribed(e.UserID, e.EventID) })
+	domain.Subscribe(bus, subscriberName, func(e domain.MessageSent) error {
+		// Connected receivers already got the message and requests wait in their own inbox
+		if !e.ReceiverOnline && !e.IsRequest {
+			return s.MessageReceived(&e.Message)
+		}
+		return nil
+	})
+}
+
+func (s *Service) PostLiked(actorID, postID int) error {
+	ownerID, err := s.store.GetPostOwnerID(postID)
+	if err != nil {
+		return fmt.Errorf("could not get the post owner: %w", err)
+	}
+
+	return s.notify(&models.NotificationReq{
+		UserID:  ownerID,
+		ActorID: actorID,
+		Type:    models.NotificationPostLiked,
+		PostID:  &postID,
+	})
+}
+
+func (s *Service) PostCommented(actorID, postID, commentID int) error {
+	ownerID, err := s.store.GetPostOwnerID(postID)
+	if err != nil {
+		return fmt.Errorf("could not get the post owner: %w", err)
+	}
+
+	return s.notify(&models.NotificationReq{
+		UserID:    ownerID,
+		ActorID:   actorID,
+		Type:      models.NotificationPostCommented,
+		PostID:    &postID,
+		CommentID: &commentID,
+	})
+}
+
+func (s *Service) UserFollowed(actorID, followedID int) error {
+	return s.notify(&models.NotificationReq{
+		UserID:  followedID,
+		ActorID: actorID,
+		Type:    models.NotificationUserFollowed,
+	})
+}
+
+func (s *Service) FollowRequested(actorID, targetID int) error {
+	return s.notify(&models.NotificationReq{
+		UserID:  targetID,
+		ActorID: actorID,
+		Type:    models.NotificationFollowRequested,
+	})
+}
+
+func (s *Service) FollowAccepted(actorID, followerID int) error {
+	return s.notify(&models.NotificationReq{
+		UserID:  followerID,
+		ActorID: actorID,
+		Type:    models.NotificationFollowAccepted,
+	})
+}
+
+func (s *Service) EventSubscribed(actorID, eventID int) error {
+	creatorID, err := s.store.GetEventCreatorID(eventID)
+	if err != nil {
+		return fmt.Errorf("could not get the event creator: %w", err)
+	}
+
+	return s.notify(&models.NotificationReq{
+		UserID:  creatorID,
+		ActorID: actorID,
+		Type:    models.NotificationEventSubscribed,
+		EventID: &eventID,
+	})
+}
+
+// EventReminders notifies the subscribers of the events that start in the next 24 hours, once per subscription
+func (s *Service) EventReminders(now time.Time) error {
+	reminders, err := s.store.GetDueEventReminders(now.Add(24 * time.Hour))
+	if err != nil {
+		return err
+	}
+
+	for _, reminder := range reminders {
+		err := s.notify(&models.NotificationReq{
+			UserID:  reminder.UserID,
+			ActorID: reminder.CreatorID,
+			Type:    models.NotificationEventReminder,
+			EventID: &reminder.EventID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := s.store.MarkEventReminded(reminder.UserID, reminder.EventID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// notify stores the notification and pushes it, the action that caused it is already committed so
+// the errors only make the event be relayed again
+func (s *Service) notify(n *models.NotificationReq) error {
+	// Nobody is notified about their own activity
+	if n.UserID == n.ActorID {
+		return nil
+	}
+
+	var notification *models.Notification
+	if s.Enabled(n.UserID, n.Type, models.NotificationChannelInApp) {
+		var err error
+		if notification, err = s.record(n); err != nil {
+			return err
+		}
+	}
+
+	if s.pusher != nil && s.Enabled(n.UserID, n.Type, models.NotificationChannelPush) {
+		s.push(n, notification)
+	}
+
+	return nil
+}
+
+// record stores the notification and publishes it, publishing is best effort
+func (s *Service) record(n *models.NotificationReq) (*models.Notification, error) {
+	id, err := s.store.CreateNotification(n)
+	if err != nil {
+		return nil, fmt.Errorf("could not create the notification: %w", err)
+	}
+
+	notification, err := s.store.GetNotificationByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the notification: %w", err)
+	}
+	notification.Text = Text(notification)
+
+	if len(s.publishers) == 0 {
+		return notification, nil
+	}
+
+	count, err := s.store.GetUnreadNotificationsCount(n.UserID)
+	if err != nil {
+		log.Println("notifications: could not get the unread count:", err)
+		return notification, nil
+	}
+
+	for _, p := range s.publishers {
+		p.PublishNotification(n.UserID, notification, *count)
+	}
+
+	return notification, nil
+}
+
+// push sends the notification to the devices, without a stored notification the text only names the actor
+func (s *Service) push(n *models.NotificationReq, notification *models.Notification) {
+	payload := &models.PushPayload{
+		Type:    n.Type,
+		Title:   "New activity",
+		PostID:  n.PostID,
+		EventID: n.EventID,
+	}
+
+	if notification != nil {
+		payload.NotificationID = &notification.ID
+		payload.Body = notification.Text
+	} else {
+		actor, err := s.store.GetUserByID(n.ActorID)
+		if err != nil {
+			log.Println("notifications: could not get the actor:", err)
+			return
+		}
+		payload.Body = Text(&models.Notification{Type: n.Type, Actors: []models.PublicUser{actor.Public()}, ActorsCount: 1})
+	}
+
+	s.pusher.Push(n.UserID, payload)
+}
+
+// MessageReceived pushes the new message to the devices of the receiver unless the conversation is muted,
+// the content of encrypted messages is never included
+func (s *Service) MessageReceived(msg *models.Message) error {
+	if s.pusher == nil || !s.Enabled(msg.Receiver.ID, models.NotificationMessage, models.NotificationChannelPush) {
+		return nil
+	}
+
+	muted, err := s.store.IsConversationMuted(msg.Receiver.ID, msg.Sender.ID)
+	if err != nil {
+		return fmt.Errorf("could not check if the conversation is muted: %w", err)
+	}
+	if muted {
+		return nil
+	}
+
+	body := "Sent you an encrypted message"
+	if msg.Mode != models.MessageModeE2E {
+		body = msg.Content
+		if runes := []rune(body); len(runes) > 120 {
+			body = string(runes[:120]) + "…"
+		}
+	}
+
+	s.pusher.Push(msg.Receiver.ID, &models.PushPayload{
+		Type:     models.NotificationMessage,
+		Title:    msg.Sender.UserName,
+		Body:     body,
+		SenderID: &msg.Sender.ID,
+	})
+
+	return nil
+}
+
+// Enabled tells if the user wants the notification type on the channel, on errors the default is used
+func (s *Service) Enabled(userID int, notificationType, channel string) bool {
+	enabled, err := s.store.IsNotificationEnabled(userID, notificationType, channel)
+	if err != nil {
+		log.Println("notifications: could not get the preferences:", err)
+		return models.DefaultNotificationPreferences()[notificationType][channel]
+	}
+
+	return enabled
+}
+
+// PublishUnreadCount sends the current unread count to the publishers, it is used when notifications are read
+func (s *Service) PublishUnreadCount(userID int) {
+	if len(s.publishers) == 0 {
+		return
+	}
+
+	count, err := s.store.GetUnreadNotificationsCount(userID)
+	if err != nil {
+		log.Println("notifications: could not get the unread count:", err)
+		return
+	}
+
+	for _, p := range s.publishers {
+		p.PublishUnreadCount(userID, *count)
+	}
+}
+
+// Text builds the aggregated sentence of the notification, like "ana and 4 others liked your post"
+func Text(n *models.Notification) string {
+	var action string
+	switch n.Type {
+	case models.NotificationPostLiked:
+		action = "liked your post"
+	case models.NotificationPostCommented:
+		action = "commented on your post"
+	case models.NotificationUserFollowed:
+		action = "started following you"
+	case models.NotificationFollowRequested:
+		action = "requested to follow you"
+	case models.NotificationFollowAccepted:
+		action = "accepted your follow request"
+	case models.NotificationEventSubscribed:
+		action = "subscribed to your event"
+	case models.NotificationEventReminder:
+		// The actor is the creator of the event, there is only one
+		if len(n.Actors) == 0 {
+			return "An event you subscribed to starts soon"
+		}
+		return fmt.Sprintf("The event of %s you subscribed to starts soon", n.Actors[0].UserName)
+	default:
+		action = "interacted with you"
+	}
+
+	if len(n.Actors) == 0 {
+		return fmt.Sprintf("Someone %s", action)
+	}
+
+	first := n.Actors[0].UserName
+	switch {
+	case n.ActorsCount <= 1:
+		return fmt.Sprintf("%s %s", first, action)
+	case n.ActorsCount == 2 && len(n.Actors) > 1:
+		return fmt.Sprintf("%s and %s %s", first, n.Actors[1].UserName, action)
+	case n.ActorsCount == 2:
+		return fmt.Sprintf("%s and 1 other %s", first, action)
+	default:
+		return fmt.Sprintf("%s and %d others %s", first, n.ActorsCount-1, action)
+	}
+}
@@ -0,0 +1,49 @@
+package notifications
+
+import (
+	"testing"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+)
+
+func TestTextSingleActor(t *testing.T) {
+	n := &models.Notification{
+		Type:        models.NotificationPostLiked,
+		Actors:      []models.PublicUser{{UserName: "ana"}},
+		ActorsCount: 1,
+	}
+
+	got := Text(n)
+	want := "ana liked your post"
+	if got != want {
+		t.Errorf("Text() = %q; want %q", got, want)
+	}
+}
+
+func TestTextTwoActors(t *testing.T) {
+	n := &models.Notification{
+		Type:        models.NotificationUserFollowed,
+		Actors:      []models.PublicUser{{UserName: "ana"}, {UserName: "marc"}},
+		ActorsCount: 2,
+	}
+
+	got := Text(n)
+	want := "ana and marc started following you"
+	if got != want {
+		t.Errorf("Text() = %q; want %q", got, want)
+	}
+}
+
+func TestTextAggregated(t *testing.T) {
+	n := &models.Notification{
+		Type:        models.NotificationPostLiked,
+		Actors:      []models.PublicUser{{UserName: "ana"}, {UserName: "marc"}, {UserName: "laia"}},
+		ActorsCount: 5,
+	}
+
+	got := Text(n)
+	want := "ana and 4 others liked your post"
+	if got != want {
+		t.Errorf("Text() = %q; want %q", got, want)
+	}
+}
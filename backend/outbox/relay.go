@@ -0,0 +1,75 @@
+package outbox
+
+import (
+	"log"
+	"time"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/domain"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/jobs"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/storage"
+)
+
+// Time a relay has to deliver the events it claimed, after it the events are claimed again
+const claimLease = 5 * time.Minute
+
+// Relay publishes in the bus the events that the storage wrote in the outbox
+type Relay struct {
+	store     storage.Storage
+	bus       *domain.Bus
+	batchSize int
+}
+
+func NewRelay(store storage.Storage, bus *domain.Bus) *Relay {
+	return &Relay{
+		store:     store,
+		bus:       bus,
+		batchSize: 100,
+	}
+}
+
+// Run relays the pending events every interval, full batches are followed by the next one right away
+func (r *Relay) Run(interval time.Duration) {
+	for {
+		relayed, err := r.RelayBatch()
+		if err != nil {
+			log.Println("outbox: could not relay the events:", err)
+		}
+
+		if err != nil || relayed < r.batchSize {
+			time.Sleep(interval)
+		}
+	}
+}
+
+// RelayBatch claims the next batch of due events, publishes them and returns how many were claimed.
+// Failed events wait with backoff, so they are not claimed again by the next batch
+func (r *Relay) RelayBatch() (int, error) {
+	events, err := r.store.ClaimOutboxEvents(r.batchSize, claimLease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, e := range events {
+		if err := r.relay(e); err != nil {
+			log.Printf("outbox: could not record the result of event %d: %s\n", e.ID, err)
+		}
+	}
+
+	return len(events), nil
+}
+
+// relay publishes the event to the subscribers that did not get it yet and records the result
+func (r *Relay) relay(e models.OutboxEvent) error {
+	event, err := domain.Decode(e.Name, e.Payload)
+	if err != nil {
+		return r.store.FailOutboxEvent(e.ID, nil, err.Error(), time.Now().Add(jobs.Backoff(e.Attempts)))
+	}
+
+	delivery := r.bus.Deliver(event, e.DeliveredTo)
+	if delivery.Err != nil {
+		return r.store.FailOutboxEvent(e.ID, delivery.Delivered, delivery.Err.Error(), time.Now().Add(jobs.Backoff(e.Attempts)))
+	}
+
+	return r.store.CompleteOutboxEvent(e.ID, delivery.Delivered)
+}
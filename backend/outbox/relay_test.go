@@ -0,0 +1,125 @@
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/domain"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/storage"
+)
+
+type fakeEvent struct {
+	models.OutboxEvent
+	nextAttemptAt time.Time
+	lastError     string
+	published     bool
+}
+
+// fakeStore keeps the outbox in memory and claims and marks the events like the Postgres store does
+type fakeStore struct {
+	storage.Storage
+	events []*fakeEvent
+}
+
+func (f *fakeStore) ClaimOutboxEvents(limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	var claimed []models.OutboxEvent
+	for _, e := range f.events {
+		if e.published || e.nextAttemptAt.After(time.Now()) || len(claimed) == limit {
+			continue
+		}
+
+		e.Attempts++
+		e.nextAttemptAt = time.Now().Add(lease)
+		claimed = append(claimed, e.OutboxEvent)
+	}
+
+	return claimed, nil
+}
+
+func (f *fakeStore) CompleteOutboxEvent(id int64, delivered []string) error {
+	e := f.find(id)
+	e.published = true
+	e.DeliveredTo = append(e.DeliveredTo, delivered...)
+	return nil
+}
+
+func (f *fakeStore) FailOutboxEvent(id int64, delivered []string, eventErr string, nextAttemptAt time.Time) error {
+	e := f.find(id)
+	e.lastError = eventErr
+	e.nextAttemptAt = nextAttemptAt
+	e.DeliveredTo = append(e.DeliveredTo, delivered...)
+	return nil
+}
+
+func (f *fakeStore) find(id int64) *fakeEvent {
+	for _, e := range f.events {
+		if e.ID == id {
+			return e
+		}
+	}
+	return nil
+}
+
+func TestRelayRetriesOnlyFailedSubscribers(t *testing.T) {
+	payload, err := json.Marshal(domain.UserFollowed{Base: domain.NewBase(), FollowerID: 1, FollowedID: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := &fakeEvent{OutboxEvent: models.OutboxEvent{ID: 1, Name: domain.UserFollowedEvent, Payload: payload}}
+	store := &fakeStore{events: []*fakeEvent{event}}
+	bus := domain.NewBus()
+
+	notified, hooked := 0, 0
+	domain.Subscribe(bus, "notifications", func(e domain.UserFollowed) error {
+		notified++
+		return nil
+	})
+	domain.Subscribe(bus, "webhooks", func(e domain.UserFollowed) error {
+		hooked++
+		if hooked == 1 {
+			return errors.New("database is down")
+		}
+		return nil
+	})
+
+	relay := NewRelay(store, bus)
+
+	if _, err := relay.RelayBatch(); err != nil {
+		t.Fatal(err)
+	}
+	if event.published || event.lastError == "" || !event.nextAttemptAt.After(time.Now()) {
+		t.Fatalf("the failed event should wait for a new attempt: %+v", event)
+	}
+
+	// The failed event waits for its backoff instead of being claimed right away
+	if relayed, err := relay.RelayBatch(); err != nil || relayed != 0 {
+		t.Fatalf("expected nothing to relay, got %d %v", relayed, err)
+	}
+
+	event.nextAttemptAt = time.Time{}
+	if _, err := relay.RelayBatch(); err != nil {
+		t.Fatal(err)
+	}
+	if !event.published || event.Attempts != 2 {
+		t.Fatalf("the event should be published on the second attempt: %+v", event)
+	}
+	if notified != 1 || hooked != 2 {
+		t.Errorf("only the failed subscriber should run again, notified %d times and hooked %d times", notified, hooked)
+	}
+}
+
+func TestRelayKeepsUnknownEvents(t *testing.T) {
+	event := &fakeEvent{OutboxEvent: models.OutboxEvent{ID: 1, Name: "unknown.event", Payload: []byte("{}")}}
+	store := &fakeStore{events: []*fakeEvent{event}}
+
+	if _, err := NewRelay(store, domain.NewBus()).RelayBatch(); err != nil {
+		t.Fatal(err)
+	}
+	if event.published || event.lastError == "" {
+		t.Errorf("the unknown event should stay pending: %+v", event)
+	}
+}
@@ -0,0 +1,87 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/middleware"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *APIServer) handleGetAthleteProfile(w http.ResponseWriter, r *http.Request) error {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return err
+	}
+
+	hidden, err := s.isHiddenFrom(r, userID)
+	if err != nil {
+		return err
+	}
+	if hidden {
+		return utils.WriteJSON(w, http.StatusNotFound, utils.APIError{Error: "user not found"})
+	}
+
+	profile, err := s.athleteProfileFor(r, userID)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the athlete profile: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"athlete_profile": profile,
+	})
+}
+
+func (s *APIServer) handleUpdateAthleteProfile(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	req := new(models.AthleteProfileReq)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if err := req.Normalize(); err != nil {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: fmt.Sprintf("invalid athlete profile: %s", err)})
+	}
+
+	profile, err := s.store.UpdateAthleteProfile(userID, req)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not update the athlete profile: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"athlete_profile": profile,
+	})
+}
+
+// athleteProfileFor returns the athlete profile of the user as the user of the JWT can see it, the owner and the admins
+// get every field with its visibility
+func (s *APIServer) athleteProfileFor(r *http.Request, userID int) (*models.AthleteProfile, error) {
+	profile, err := s.store.GetAthleteProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	viewerID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return nil, fmt.Errorf("failed to get user id from JWT")
+	}
+	if role, _ := r.Context().Value(middleware.UserRoleKey).(string); viewerID == userID || role == "admin" {
+		return profile, nil
+	}
+
+	isFollower, err := s.store.CheckIfFollowing(userID, viewerID) // Tells if the viewer follows the user
+	if err != nil {
+		return nil, err
+	}
+
+	return profile.VisibleTo(isFollower), nil
+}
@@ -0,0 +1,140 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/middleware"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+func validateBadge(req *models.BadgeReq) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		return fmt.Errorf("the name is required and can have at most 100 characters")
+	}
+
+	if req.Rule == nil {
+		if req.Threshold != nil {
+			return fmt.Errorf("a threshold needs a rule")
+		}
+		return nil
+	}
+
+	if !slices.Contains(models.BadgeRules, *req.Rule) {
+		return fmt.Errorf("unknown rule: %s", *req.Rule)
+	}
+	if req.Threshold == nil || *req.Threshold <= 0 {
+		return fmt.Errorf("a rule needs a positive threshold")
+	}
+
+	return nil
+}
+
+func (s *APIServer) handleCreateBadge(w http.ResponseWriter, r *http.Request) error {
+	req := new(models.BadgeReq)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if err := validateBadge(req); err != nil {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: err.Error()})
+	}
+
+	// The users that already meet the rule get the badge on the next run of the award-badges task
+	badge, err := s.store.CreateBadge(req)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not create the badge: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusCreated, badge)
+}
+
+func (s *APIServer) handleGetBadges(w http.ResponseWriter, r *http.Request) error {
+	badges, err := s.store.GetBadges()
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the badges: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"badges": badges,
+	})
+}
+
+func (s *APIServer) handleDeleteBadge(w http.ResponseWriter, r *http.Request) error {
+	badgeID, err := strconv.Atoi(chi.URLParam(r, "badgeID"))
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.DeleteBadge(badgeID); err != nil {
+		return utils.WriteJSON(w, http.StatusNotFound, utils.APIError{Error: fmt.Sprintf("could not delete the badge: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *APIServer) handleGetUserBadges(w http.ResponseWriter, r *http.Request) error {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return err
+	}
+
+	hidden, err := s.isHiddenFrom(r, userID)
+	if err != nil {
+		return err
+	}
+	if hidden {
+		return utils.WriteJSON(w, http.StatusNotFound, utils.APIError{Error: "user not found"})
+	}
+
+	badges, err := s.store.GetUserBadges(userID)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the badges: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"badges": badges,
+	})
+}
+
+func (s *APIServer) handleAwardBadge(w http.ResponseWriter, r *http.Request) error {
+	adminID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	return s.updateUserBadge(w, r, func(userID, badgeID int) error {
+		return s.store.AwardBadge(userID, badgeID, adminID)
+	}, "could not award the badge")
+}
+
+func (s *APIServer) handleRevokeBadge(w http.ResponseWriter, r *http.Request) error {
+	return s.updateUserBadge(w, r, s.store.RevokeBadge, "could not revoke the badge")
+}
+
+// updateUserBadge awards or revokes the url badge to the url user
+func (s *APIServer) updateUserBadge(w http.ResponseWriter, r *http.Request, update func(userID, badgeID int) error, errMsg string) error {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return err
+	}
+
+	badgeID, err := strconv.Atoi(chi.URLParam(r, "badgeID"))
+	if err != nil {
+		return err
+	}
+
+	if err := update(userID, badgeID); err != nil {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: fmt.Sprintf("%s: %s", errMsg, err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
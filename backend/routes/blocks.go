@@ -0,0 +1,90 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/middleware"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *APIServer) handleBlockUser(w http.ResponseWriter, r *http.Request) error {
+	return s.updateUserRelation(w, r, s.store.BlockUser, "could not block the user")
+}
+
+func (s *APIServer) handleUnblockUser(w http.ResponseWriter, r *http.Request) error {
+	return s.updateUserRelation(w, r, s.store.UnblockUser, "could not unblock the user")
+}
+
+func (s *APIServer) handleMuteUser(w http.ResponseWriter, r *http.Request) error {
+	return s.updateUserRelation(w, r, s.store.MuteUser, "could not mute the user")
+}
+
+func (s *APIServer) handleUnmuteUser(w http.ResponseWriter, r *http.Request) error {
+	return s.updateUserRelation(w, r, s.store.UnmuteUser, "could not unmute the user")
+}
+
+// updateUserRelation applies a change between the JWT user and the url user, like blocking or muting
+func (s *APIServer) updateUserRelation(w http.ResponseWriter, r *http.Request, update func(userID, otherUserID int) error, errMsg string) error {
+	otherUserID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return err
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	if otherUserID == userID {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: fmt.Sprintf("%s: it is yourself", errMsg)})
+	}
+
+	if err := update(userID, otherUserID); err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("%s: %s", errMsg, err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *APIServer) handleGetBlockedUsers(w http.ResponseWriter, r *http.Request) error {
+	return s.writeRelatedUsers(w, r, s.store.GetBlockedUsers)
+}
+
+func (s *APIServer) handleGetMutedUsers(w http.ResponseWriter, r *http.Request) error {
+	return s.writeRelatedUsers(w, r, s.store.GetMutedUsers)
+}
+
+func (s *APIServer) writeRelatedUsers(w http.ResponseWriter, r *http.Request, get func(userID int) ([]models.PublicUser, error)) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	users, err := get(userID)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the users: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"users": users,
+	})
+}
+
+// isHiddenFrom tells if the user must be hidden from the user of the JWT because one of them blocked the other,
+// admins see every user
+func (s *APIServer) isHiddenFrom(r *http.Request, userID int) (bool, error) {
+	viewerID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return false, fmt.Errorf("failed to get user id from JWT")
+	}
+
+	if role, _ := r.Context().Value(middleware.UserRoleKey).(string); role == "admin" {
+		return false, nil
+	}
+
+	return s.store.IsBlocked(userID, viewerID)
+}
@@ -2,12 +2,14 @@ package routes
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/Marc-Garcia-Coronado/socialNetwork/middleware"
 	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/storage"
 	"github.com/Marc-Garcia-Coronado/socialNetwork/utils"
 	"github.com/go-chi/chi/v5"
 )
@@ -18,6 +20,11 @@ func (s *APIServer) handleGetPostComments(w http.ResponseWriter, r *http.Request
 		return err
 	}
 
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
 	// Get pagination query params
 	limitStr := r.URL.Query().Get("limit")
 	pageStr := r.URL.Query().Get("page")
@@ -43,7 +50,7 @@ func (s *APIServer) handleGetPostComments(w http.ResponseWriter, r *http.Request
 	// Calculate offset
 	offset := (page - 1) * limit
 
-	comments, count, err := s.store.GetPostComments(postID, limit, offset)
+	comments, count, err := s.store.GetPostComments(postID, userID, limit, offset)
 	if err != nil {
 		return utils.WriteJSON(w, http.StatusInternalServerError, fmt.Sprintf("could not get the comments: %s", err))
 	}
@@ -78,6 +85,9 @@ func (s *APIServer) handleCreatePostComment(w http.ResponseWriter, r *http.Reque
 	commentReq.PostID = postID
 
 	comment, err := s.store.CreateComment(commentReq)
+	if errors.Is(err, storage.ErrBlocked) {
+		return utils.WriteJSON(w, http.StatusForbidden, utils.APIError{Error: fmt.Sprintf("could not commment the post: %s", err)})
+	}
 	if err != nil {
 		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not commment the post: %s", err)})
 	}
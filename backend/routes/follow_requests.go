@@ -0,0 +1,184 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/middleware"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *APIServer) handleGetAccountPrivacy(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	isPrivate, err := s.store.GetAccountPrivacy(userID)
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, models.AccountPrivacyReq{
+		IsPrivate: isPrivate,
+	})
+}
+
+func (s *APIServer) handleUpdateAccountPrivacy(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	privacyReq := new(models.AccountPrivacyReq)
+	if err := json.NewDecoder(r.Body).Decode(privacyReq); err != nil {
+		return err
+	}
+
+	if err := s.store.SetAccountPrivacy(userID, privacyReq.IsPrivate); err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not update the account privacy: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, privacyReq)
+}
+
+func (s *APIServer) handleGetFollowRequests(w http.ResponseWriter, r *http.Request) error {
+	return s.writeFollowRequests(w, r, s.store.GetFollowRequests)
+}
+
+func (s *APIServer) handleGetSentFollowRequests(w http.ResponseWriter, r *http.Request) error {
+	return s.writeFollowRequests(w, r, s.store.GetSentFollowRequests)
+}
+
+// writeFollowRequests writes a page of the follow requests of the user of the JWT
+func (s *APIServer) writeFollowRequests(w http.ResponseWriter, r *http.Request, get func(userID, limit, offset int) ([]models.FollowRequest, int, error)) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	// Get pagination query params
+	limitStr := r.URL.Query().Get("limit")
+	pageStr := r.URL.Query().Get("page")
+
+	// Set default values if params are missing
+	limit := 10 // Default limit
+	page := 1   // Default page
+
+	var err error
+	if limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "Invalid limit"})
+		}
+	}
+
+	if pageStr != "" {
+		page, err = strconv.Atoi(pageStr)
+		if err != nil || page <= 0 {
+			return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "Invalid page"})
+		}
+	}
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	requests, count, err := get(userID, limit, offset)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the follow requests: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, models.FollowRequestsWithPagination{
+		Requests: requests,
+		Pagination: models.Pagination{
+			TotalCount: count,
+			Page:       page,
+			Limit:      limit,
+		},
+	})
+}
+
+func (s *APIServer) handleApproveFollowRequest(w http.ResponseWriter, r *http.Request) error {
+	requesterID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		return err
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	if err := s.store.ApproveFollowRequest(requesterID, userID); err != nil {
+		return utils.WriteJSON(w, http.StatusNotFound, utils.APIError{Error: fmt.Sprintf("could not approve the follow request: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *APIServer) handleRejectFollowRequest(w http.ResponseWriter, r *http.Request) error {
+	requesterID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		return err
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	if err := s.store.DeleteFollowRequest(requesterID, userID); err != nil {
+		return utils.WriteJSON(w, http.StatusNotFound, utils.APIError{Error: fmt.Sprintf("could not reject the follow request: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *APIServer) handleCancelFollowRequest(w http.ResponseWriter, r *http.Request) error {
+	targetID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		return err
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	if err := s.store.DeleteFollowRequest(userID, targetID); err != nil {
+		return utils.WriteJSON(w, http.StatusNotFound, utils.APIError{Error: fmt.Sprintf("could not cancel the follow request: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+// canViewProfile tells if the user of the JWT can see the posts and follows of the user, admins see every profile
+func (s *APIServer) canViewProfile(r *http.Request, userID int) (bool, error) {
+	viewerID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return false, fmt.Errorf("failed to get user id from JWT")
+	}
+
+	if role, _ := r.Context().Value(middleware.UserRoleKey).(string); role == "admin" {
+		return true, nil
+	}
+
+	return s.store.CanViewProfile(userID, viewerID)
+}
+
+// checkProfileAccess writes the error response and returns false when the profile is not visible to the user of the JWT
+func (s *APIServer) checkProfileAccess(w http.ResponseWriter, r *http.Request, userID int) (bool, error) {
+	canView, err := s.canViewProfile(r, userID)
+	if err != nil {
+		return false, utils.WriteJSON(w, http.StatusNotFound, utils.APIError{Error: fmt.Sprintf("could not get the user: %s", err)})
+	}
+	if !canView {
+		return false, utils.WriteJSON(w, http.StatusForbidden, utils.APIError{Error: "this account is private"})
+	}
+
+	return true, nil
+}
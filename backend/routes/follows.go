@@ -1,12 +1,14 @@
 package routes
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/Marc-Garcia-Coronado/socialNetwork/middleware"
 	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/storage"
 	"github.com/Marc-Garcia-Coronado/socialNetwork/utils"
 	"github.com/go-chi/chi/v5"
 )
@@ -22,12 +24,20 @@ func (s *APIServer) handleFollowUser(w http.ResponseWriter, r *http.Request) err
 		return fmt.Errorf("failed to get user id from JWT")
 	}
 
-	err = s.store.FollowUser(userToFollowID, id)
+	status, err := s.store.FollowUser(userToFollowID, id)
+	if errors.Is(err, storage.ErrBlocked) {
+		return utils.WriteJSON(w, http.StatusForbidden, utils.APIError{Error: fmt.Sprintf("could not follow the user: %s", err)})
+	}
 	if err != nil {
 		return err
 	}
 
-	return utils.WriteJSON(w, http.StatusCreated, nil)
+	// Private accounts have to approve the request before the follow exists
+	if status == models.FollowStatusRequested {
+		return utils.WriteJSON(w, http.StatusAccepted, map[string]string{"status": status})
+	}
+
+	return utils.WriteJSON(w, http.StatusCreated, map[string]string{"status": status})
 }
 
 func (s *APIServer) handleUnfollowUser(w http.ResponseWriter, r *http.Request) error {
@@ -55,6 +65,10 @@ func (s *APIServer) handleGetFollowers(w http.ResponseWriter, r *http.Request) e
 		return err
 	}
 
+	if ok, err := s.checkProfileAccess(w, r, userToFollowID); !ok {
+		return err
+	}
+
 	// Get pagination query params
 	limitStr := r.URL.Query().Get("limit")
 	pageStr := r.URL.Query().Get("page")
@@ -101,6 +115,10 @@ func (s *APIServer) handleGetUserFollows(w http.ResponseWriter, r *http.Request)
 		return err
 	}
 
+	if ok, err := s.checkProfileAccess(w, r, userToFollowID); !ok {
+		return err
+	}
+
 	// Get pagination query params
 	limitStr := r.URL.Query().Get("limit")
 	pageStr := r.URL.Query().Get("page")
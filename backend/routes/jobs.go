@@ -0,0 +1,86 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// handleGetJobs lists the jobs by status, by default the dead letter
+func (s *APIServer) handleGetJobs(w http.ResponseWriter, r *http.Request) error {
+	var err error
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = models.JobDead
+	case models.JobPending, models.JobRunning, models.JobDone, models.JobDead:
+	default:
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "invalid status"})
+	}
+
+	// Get pagination query params
+	limitStr := r.URL.Query().Get("limit")
+	pageStr := r.URL.Query().Get("page")
+
+	// Set default values if params are missing
+	limit := 10 // Default limit
+	page := 1   // Default page
+
+	if limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "invalid limit"})
+		}
+	}
+
+	if pageStr != "" {
+		page, err = strconv.Atoi(pageStr)
+		if err != nil || page <= 0 {
+			return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "invalid page"})
+		}
+	}
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	list, count, err := s.store.GetJobs(status, limit, offset)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the jobs: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, models.JobsWithPagination{
+		Jobs: list,
+		Pagination: models.Pagination{
+			TotalCount: count,
+			Page:       page,
+			Limit:      limit,
+		},
+	})
+}
+
+func (s *APIServer) handleRetryJob(w http.ResponseWriter, r *http.Request) error {
+	jobID, err := strconv.ParseInt(chi.URLParam(r, "jobID"), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.RequeueDeadJob(jobID); err != nil {
+		return utils.WriteJSON(w, http.StatusNotFound, utils.APIError{Error: fmt.Sprintf("could not retry the job: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *APIServer) handleGetScheduledTasks(w http.ResponseWriter, r *http.Request) error {
+	tasks, err := s.scheduler.Tasks()
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the scheduled tasks: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, tasks)
+}
@@ -0,0 +1,191 @@
+package routes
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/middleware"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/storage"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	maxDeviceIDLength   = 64
+	maxKeyLength        = 1024
+	maxPreKeysPerUpload = 100
+	maxCiphertextLength = 64 * 1024
+	maxMessageDevices   = 20
+)
+
+func (s *APIServer) handlePublishKeys(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	deviceID := chi.URLParam(r, "deviceID")
+	if deviceID == "" || len(deviceID) > maxDeviceIDLength {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "invalid device id"})
+	}
+
+	keysReq := new(models.PublishKeysReq)
+	if err := json.NewDecoder(r.Body).Decode(keysReq); err != nil {
+		return err
+	}
+
+	if !isValidKey(keysReq.IdentityKey) || !isValidKey(keysReq.SignedPreKey.PublicKey) || !isValidKey(keysReq.SignedPreKey.Signature) {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "identity key and signed pre key must be base64 encoded"})
+	}
+
+	if err := validatePreKeys(keysReq.OneTimePreKeys); err != nil {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: err.Error()})
+	}
+
+	if err := s.store.PublishKeys(userID, deviceID, keysReq); err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not publish the keys: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *APIServer) handleUploadPreKeys(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	deviceID := chi.URLParam(r, "deviceID")
+	if deviceID == "" || len(deviceID) > maxDeviceIDLength {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "invalid device id"})
+	}
+
+	preKeysReq := new(models.UploadPreKeysReq)
+	if err := json.NewDecoder(r.Body).Decode(preKeysReq); err != nil {
+		return err
+	}
+
+	if len(preKeysReq.OneTimePreKeys) == 0 {
+		return fmt.Errorf("no pre keys provided")
+	}
+
+	if err := validatePreKeys(preKeysReq.OneTimePreKeys); err != nil {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: err.Error()})
+	}
+
+	if err := s.store.UploadPreKeys(userID, deviceID, preKeysReq.OneTimePreKeys); err != nil {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: fmt.Sprintf("could not upload the pre keys: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *APIServer) handleGetDevices(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	devices, err := s.store.GetUserDevices(userID)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the devices: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"devices": devices,
+	})
+}
+
+func (s *APIServer) handleDeleteDevice(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	if err := s.store.DeleteDevice(userID, chi.URLParam(r, "deviceID")); err != nil {
+		return utils.WriteJSON(w, http.StatusNotFound, utils.APIError{Error: err.Error()})
+	}
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *APIServer) handleGetKeyBundles(w http.ResponseWriter, r *http.Request) error {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return err
+	}
+
+	requesterID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	bundles, err := s.store.GetKeyBundles(requesterID, userID)
+	if errors.Is(err, storage.ErrBlocked) {
+		return utils.WriteJSON(w, http.StatusForbidden, utils.APIError{Error: fmt.Sprintf("could not get the key bundles: %s", err)})
+	}
+	if errors.Is(err, storage.ErrTooManyKeyBundleFetches) {
+		return utils.WriteJSON(w, http.StatusTooManyRequests, utils.APIError{Error: fmt.Sprintf("could not get the key bundles: %s", err)})
+	}
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the key bundles: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"bundles": bundles,
+	})
+}
+
+func isValidKey(key string) bool {
+	if key == "" || len(key) > maxKeyLength {
+		return false
+	}
+
+	_, err := base64.StdEncoding.DecodeString(key)
+	return err == nil
+}
+
+func validatePreKeys(preKeys []models.OneTimePreKey) error {
+	if len(preKeys) > maxPreKeysPerUpload {
+		return fmt.Errorf("too many pre keys, the maximum is %d", maxPreKeysPerUpload)
+	}
+
+	for _, preKey := range preKeys {
+		if !isValidKey(preKey.PublicKey) {
+			return fmt.Errorf("pre key %d must be base64 encoded", preKey.KeyID)
+		}
+	}
+
+	return nil
+}
+
+// validateMessageMode checks the shape of the message, encrypted payloads are never looked into
+func validateMessageMode(msg *models.WSMessageReq) error {
+	switch msg.Mode {
+	case "", models.MessageModePlain:
+		if len(msg.Ciphertexts) > 0 {
+			return fmt.Errorf("plain messages cannot have ciphertexts")
+		}
+	case models.MessageModeE2E:
+		if msg.Content != "" {
+			return fmt.Errorf("encrypted messages cannot have plain content")
+		}
+		if len(msg.Ciphertexts) == 0 || len(msg.Ciphertexts) > maxMessageDevices {
+			return fmt.Errorf("encrypted messages need between 1 and %d ciphertexts", maxMessageDevices)
+		}
+		for _, c := range msg.Ciphertexts {
+			if c.Ciphertext == "" || len(c.Ciphertext) > maxCiphertextLength {
+				return fmt.Errorf("invalid ciphertext for device %s", c.DeviceID)
+			}
+		}
+	default:
+		return fmt.Errorf("invalid message mode")
+	}
+
+	return nil
+}
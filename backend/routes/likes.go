@@ -1,12 +1,14 @@
 package routes
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/Marc-Garcia-Coronado/socialNetwork/middleware"
 	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/storage"
 	"github.com/Marc-Garcia-Coronado/socialNetwork/utils"
 	"github.com/go-chi/chi/v5"
 )
@@ -23,6 +25,9 @@ func (s *APIServer) handleLikePost(w http.ResponseWriter, r *http.Request) error
 	}
 
 	err = s.store.LikePost(userID, postID)
+	if errors.Is(err, storage.ErrBlocked) {
+		return utils.WriteJSON(w, http.StatusForbidden, utils.APIError{Error: fmt.Sprintf("could not like the post: %s", err)})
+	}
 	if err != nil {
 		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not like the post: %s", err)})
 	}
@@ -42,6 +47,9 @@ func (s *APIServer) handleLikeComment(w http.ResponseWriter, r *http.Request) er
 	}
 
 	err = s.store.LikeComment(userID, commentID)
+	if errors.Is(err, storage.ErrBlocked) {
+		return utils.WriteJSON(w, http.StatusForbidden, utils.APIError{Error: fmt.Sprintf("could not like the comment: %s", err)})
+	}
 	if err != nil {
 		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not like the comment: %s", err)})
 	}
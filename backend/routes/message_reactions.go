@@ -0,0 +1,199 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/middleware"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/storage"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *APIServer) handleAddMessageReaction(w http.ResponseWriter, r *http.Request) error {
+	messageID, err := strconv.Atoi(chi.URLParam(r, "messageID"))
+	if err != nil {
+		return err
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	reactionReq := new(models.MessageReactionReq)
+	if err := json.NewDecoder(r.Body).Decode(reactionReq); err != nil {
+		return err
+	}
+
+	if !isValidEmoji(reactionReq.Emoji) {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "invalid emoji"})
+	}
+
+	err = s.store.AddMessageReaction(messageID, userID, reactionReq.Emoji)
+	if errors.Is(err, storage.ErrBlocked) {
+		return utils.WriteJSON(w, http.StatusForbidden, utils.APIError{Error: fmt.Sprintf("could not react to the message: %s", err)})
+	}
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: fmt.Sprintf("could not react to the message: %s", err)})
+	}
+
+	s.broadcastReaction("reaction_added", messageID, userID, reactionReq.Emoji)
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *APIServer) handleRemoveMessageReaction(w http.ResponseWriter, r *http.Request) error {
+	messageID, err := strconv.Atoi(chi.URLParam(r, "messageID"))
+	if err != nil {
+		return err
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	emoji := r.URL.Query().Get("emoji")
+	if !isValidEmoji(emoji) {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "invalid emoji"})
+	}
+
+	if err := s.store.RemoveMessageReaction(messageID, userID, emoji); err != nil {
+		return utils.WriteJSON(w, http.StatusNotFound, utils.APIError{Error: fmt.Sprintf("could not remove the reaction: %s", err)})
+	}
+
+	s.broadcastReaction("reaction_removed", messageID, userID, emoji)
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+// broadcastReaction sends the reaction event to both participants of the message
+func (s *APIServer) broadcastReaction(eventType string, messageID, userID int, emoji string) {
+	senderID, receiverID, err := s.store.GetMessageParticipants(messageID)
+	if err != nil {
+		return
+	}
+
+	event := models.WSEvent{
+		Type: eventType,
+		Data: models.MessageReactionEvent{
+			MessageID: messageID,
+			UserID:    userID,
+			Emoji:     emoji,
+		},
+	}
+	sendToUser(senderID, event)
+	sendToUser(receiverID, event)
+}
+
+// isValidEmoji checks the reaction is a single emoji: a pictograph with its variation selector, skin tone and tags,
+// optionally joined to more pictographs with zero width joiners like 👩‍💻, a flag made of two regional indicators
+// or a keycap like 1️⃣
+func isValidEmoji(emoji string) bool {
+	if emoji == "" || len(emoji) > 32 {
+		return false
+	}
+
+	runes := []rune(emoji)
+	switch r := runes[0]; {
+	case isKeycapBase(r):
+		// A digit, # or * only is an emoji as a keycap, the variation selector is optional
+		i := 1
+		if i < len(runes) && runes[i] == 0xFE0F {
+			i++
+		}
+		return i == len(runes)-1 && runes[i] == 0x20E3
+	case isRegionalIndicator(r):
+		return len(runes) == 2 && isRegionalIndicator(runes[1])
+	case isPictograph(r):
+		i := 1
+		for {
+			for i < len(runes) && isEmojiModifier(runes[i]) {
+				i++
+			}
+			// The joiner glues the next pictograph to this one, anything else ends the emoji
+			if i+1 >= len(runes) || runes[i] != 0x200D || !isPictograph(runes[i+1]) {
+				break
+			}
+			i += 2
+		}
+		return i == len(runes)
+	default:
+		return false
+	}
+}
+
+func isKeycapBase(r rune) bool {
+	return (r >= '0' && r <= '9') || r == '#' || r == '*'
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// isEmojiModifier tells if the rune changes the previous pictograph: the emoji variation selector,
+// the skin tones and the tags of the subdivision flags
+func isEmojiModifier(r rune) bool {
+	return r == 0xFE0F || (r >= 0x1F3FB && r <= 0x1F3FF) || (r >= 0xE0020 && r <= 0xE007F)
+}
+
+// emojiRanges are the blocks of pictographs, they leave out the letters, digits and math symbols
+var emojiRanges = [][2]rune{
+	{0x1F000, 0x1F0FF}, // Mahjong, domino and playing cards
+	{0x1F170, 0x1F1E5}, // Enclosed alphanumerics like 🅰️ and 🆗
+	{0x1F200, 0x1F2FF}, // Enclosed ideographs like 🈚
+	{0x1F300, 0x1F3FA}, // Misc symbols and pictographs, until the skin tones
+	{0x1F400, 0x1F6FF}, // Pictographs, emoticons and transport
+	{0x1F7E0, 0x1F7FF}, // Geometric shapes like 🟠
+	{0x1F900, 0x1FAFF}, // Supplemental symbols and pictographs
+	{0x2600, 0x27BF},   // Misc symbols and dingbats like ☀️ and ✅
+	{0x231A, 0x231B},   // ⌚ ⌛
+	{0x23E9, 0x23FA},   // ⏩ to ⏺
+	{0x2B05, 0x2B07},   // ⬅️ ⬆️ ⬇️
+	{0x2B1B, 0x2B1C},   // ⬛ ⬜
+	{0x25AA, 0x25AB},   // ▪️ ▫️
+	{0x25FB, 0x25FE},   // ◻️ to ◾
+	{0x2194, 0x2199},   // ↔️ to ↙️
+	{0x21A9, 0x21AA},   // ↩️ ↪️
+	{0x2934, 0x2935},   // ⤴️ ⤵️
+}
+
+// emojiRunes are the pictographs outside the blocks
+var emojiRunes = map[rune]bool{
+	0x00A9: true, // ©️
+	0x00AE: true, // ®️
+	0x203C: true, // ‼️
+	0x2049: true, // ⁉️
+	0x2122: true, // ™️
+	0x2139: true, // ℹ️
+	0x2328: true, // ⌨️
+	0x23CF: true, // ⏏️
+	0x24C2: true, // Ⓜ️
+	0x25B6: true, // ▶️
+	0x25C0: true, // ◀️
+	0x2B50: true, // ⭐
+	0x2B55: true, // ⭕
+	0x3030: true, // 〰️
+	0x303D: true, // 〽️
+	0x3297: true, // ㊗️
+	0x3299: true, // ㊙️
+}
+
+func isPictograph(r rune) bool {
+	if emojiRunes[r] {
+		return true
+	}
+
+	for _, rg := range emojiRanges {
+		if r >= rg[0] && r <= rg[1] {
+			return true
+		}
+	}
+
+	return false
+}
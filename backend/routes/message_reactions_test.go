@@ -0,0 +1,39 @@
+package routes
+
+import "testing"
+
+func TestIsValidEmoji(t *testing.T) {
+	tests := []struct {
+		name  string
+		emoji string
+		want  bool
+	}{
+		{"pictograph", "👍", true},
+		{"variation selector", "❤️", true},
+		{"skin tone", "👍🏽", true},
+		{"zwj sequence", "👩‍💻", true},
+		{"zwj sequence with skin tones", "🧑🏻‍🤝‍🧑🏿", true},
+		{"family", "👨‍👩‍👧‍👦", true},
+		{"flag", "🇪🇸", true},
+		{"subdivision flag", "🏴󠁧󠁢󠁳󠁣󠁴󠁿", true},
+		{"keycap", "1️⃣", true},
+		{"keycap without variation selector", "#⃣", true},
+		{"empty", "", false},
+		{"plain text", "ok", false},
+		{"digit", "1", false},
+		{"pictograph and text", "👍a", false},
+		{"two pictographs", "👍👍", false},
+		{"two flags", "🇪🇸🇫🇷", false},
+		{"half a flag", "🇪", false},
+		{"two keycaps", "1️⃣2️⃣", false},
+		{"lone skin tone", "🏽", false},
+		{"trailing joiner", "👩‍", false},
+		{"joiner to text", "👩‍a", false},
+	}
+
+	for _, tt := range tests {
+		if got := isValidEmoji(tt.emoji); got != tt.want {
+			t.Errorf("%s: isValidEmoji(%q) = %v, want %v", tt.name, tt.emoji, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/middleware"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *APIServer) handleGetMessageRequests(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	requests, err := s.store.GetMessageRequests(userID)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the message requests: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"requests": requests,
+	})
+}
+
+func (s *APIServer) handleAcceptMessageRequest(w http.ResponseWriter, r *http.Request) error {
+	senderID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		return err
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	if err := s.store.AcceptMessageRequest(senderID, userID); err != nil {
+		return utils.WriteJSON(w, http.StatusNotFound, utils.APIError{Error: fmt.Sprintf("could not accept the message request: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *APIServer) handleDeclineMessageRequest(w http.ResponseWriter, r *http.Request) error {
+	senderID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		return err
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	if err := s.store.DeclineMessageRequest(senderID, userID); err != nil {
+		return utils.WriteJSON(w, http.StatusNotFound, utils.APIError{Error: fmt.Sprintf("could not decline the message request: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *APIServer) handleBlockMessageRequest(w http.ResponseWriter, r *http.Request) error {
+	senderID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		return err
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	if senderID == userID {
+		return fmt.Errorf("you cannot block yourself")
+	}
+
+	if err := s.store.BlockMessageRequest(senderID, userID); err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not block the user messages: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *APIServer) handleGetMessagePrivacy(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	privacy, err := s.store.GetMessagePrivacy(userID)
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, models.MessagePrivacyReq{
+		MessagePrivacy: privacy,
+	})
+}
+
+func (s *APIServer) handleUpdateMessagePrivacy(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	privacyReq := new(models.MessagePrivacyReq)
+	if err := json.NewDecoder(r.Body).Decode(privacyReq); err != nil {
+		return err
+	}
+
+	switch privacyReq.MessagePrivacy {
+	case models.MessagePrivacyEveryone, models.MessagePrivacyFollowers, models.MessagePrivacyNobody:
+	default:
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "invalid message privacy"})
+	}
+
+	if err := s.store.UpdateMessagePrivacy(userID, privacyReq.MessagePrivacy); err != nil {
+		return err
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, privacyReq)
+}
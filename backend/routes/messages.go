@@ -1,11 +1,16 @@
 package routes
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/Marc-Garcia-Coronado/socialNetwork/middleware"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/storage"
 	"github.com/Marc-Garcia-Coronado/socialNetwork/utils"
 	"github.com/go-chi/chi/v5"
 )
@@ -16,7 +21,17 @@ func (s *APIServer) handleGetConversations(w http.ResponseWriter, r *http.Reques
 		return fmt.Errorf("failed to get user id from JWT")
 	}
 
-	conversations, err := s.store.GetUserConversations(userID)
+	filter := r.URL.Query().Get("filter")
+	switch filter {
+	case "":
+		filter = models.ConversationFilterInbox
+	case models.ConversationFilterInbox, models.ConversationFilterArchived, models.ConversationFilterPinned,
+		models.ConversationFilterMuted, models.ConversationFilterAll:
+	default:
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "invalid filter"})
+	}
+
+	conversations, err := s.store.GetUserConversations(userID, filter)
 	if err != nil {
 		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the user conversations: %s", err)})
 	}
@@ -41,6 +56,20 @@ func (s *APIServer) handleGetConversationMessages(w http.ResponseWriter, r *http
 		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the user messages: %s", err)})
 	}
 
+	// Encrypted messages only carry the ciphertext of the device asking for them
+	if deviceID := r.URL.Query().Get("device_id"); deviceID != "" {
+		ciphertexts, err := s.store.GetConversationCiphertexts(userID, toUserID, deviceID)
+		if err != nil {
+			return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the messages ciphertexts: %s", err)})
+		}
+
+		for i := range messages {
+			if ciphertext, ok := ciphertexts[messages[i].ID]; ok {
+				messages[i].Ciphertext = &ciphertext
+			}
+		}
+	}
+
 	return utils.WriteJSON(w, http.StatusOK, map[string]any{
 		"messages": messages,
 	})
@@ -85,3 +114,145 @@ func (s *APIServer) handleGetNotReadedConversationMessages(w http.ResponseWriter
 		"number": numberMsg,
 	})
 }
+
+func (s *APIServer) handlePinConversation(w http.ResponseWriter, r *http.Request) error {
+	return s.updateConversationFlag(w, r, s.store.PinConversation, true)
+}
+
+func (s *APIServer) handleUnpinConversation(w http.ResponseWriter, r *http.Request) error {
+	return s.updateConversationFlag(w, r, s.store.PinConversation, false)
+}
+
+func (s *APIServer) handleArchiveConversation(w http.ResponseWriter, r *http.Request) error {
+	return s.updateConversationFlag(w, r, s.store.ArchiveConversation, true)
+}
+
+func (s *APIServer) handleUnarchiveConversation(w http.ResponseWriter, r *http.Request) error {
+	return s.updateConversationFlag(w, r, s.store.ArchiveConversation, false)
+}
+
+// updateConversationFlag sets a boolean setting of the conversation between the JWT user and the url user
+func (s *APIServer) updateConversationFlag(w http.ResponseWriter, r *http.Request, update func(userID, otherUserID int, value bool) error, value bool) error {
+	otherUserID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		return err
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	if err := update(userID, otherUserID, value); err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not update the conversation: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *APIServer) handleMuteConversation(w http.ResponseWriter, r *http.Request) error {
+	otherUserID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		return err
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	muteReq := new(models.MuteConversationReq)
+	if err := json.NewDecoder(r.Body).Decode(muteReq); err != nil {
+		return err
+	}
+
+	until, err := time.Parse(time.RFC3339, muteReq.Until)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "invalid until date, it must be RFC3339"})
+	}
+
+	if !until.After(time.Now()) {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "until date must be in the future"})
+	}
+
+	if err := s.store.MuteConversation(userID, otherUserID, &until); err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not mute the conversation: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *APIServer) handleUnmuteConversation(w http.ResponseWriter, r *http.Request) error {
+	otherUserID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		return err
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	if err := s.store.MuteConversation(userID, otherUserID, nil); err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not unmute the conversation: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *APIServer) handleGetConversationRetention(w http.ResponseWriter, r *http.Request) error {
+	otherUserID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		return err
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	retention, err := s.store.GetConversationRetention(userID, otherUserID)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the conversation retention: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, retention)
+}
+
+func (s *APIServer) handleUpdateConversationRetention(w http.ResponseWriter, r *http.Request) error {
+	otherUserID, err := strconv.Atoi(chi.URLParam(r, "userID"))
+	if err != nil {
+		return err
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	retentionReq := new(models.ConversationRetentionReq)
+	if err := json.NewDecoder(r.Body).Decode(retentionReq); err != nil {
+		return err
+	}
+
+	seconds, ok := models.MessageRetentions[retentionReq.Retention]
+	if !ok {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "invalid retention, use off, 24h, 7d or 90d"})
+	}
+
+	systemMsg, err := s.store.SetConversationRetention(userID, otherUserID, seconds, retentionReq.Retention)
+	if errors.Is(err, storage.ErrBlocked) || errors.Is(err, storage.ErrMessageRequestPending) {
+		return utils.WriteJSON(w, http.StatusForbidden, utils.APIError{Error: fmt.Sprintf("could not update the conversation retention: %s", err)})
+	}
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: fmt.Sprintf("could not update the conversation retention: %s", err)})
+	}
+
+	// Both participants see the change in the conversation
+	sendToUser(otherUserID, systemMsg)
+	sendToUser(userID, systemMsg)
+
+	return utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"message": systemMsg,
+	})
+}
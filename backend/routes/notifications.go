@@ -0,0 +1,211 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/middleware"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/notifications"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *APIServer) handleGetNotifications(w http.ResponseWriter, r *http.Request) error {
+	var err error
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	// Get pagination query params
+	limitStr := r.URL.Query().Get("limit")
+	pageStr := r.URL.Query().Get("page")
+
+	// Set default values if params are missing
+	limit := 10 // Default limit
+	page := 1   // Default page
+
+	if limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "invalid limit"})
+		}
+	}
+
+	if pageStr != "" {
+		page, err = strconv.Atoi(pageStr)
+		if err != nil || page <= 0 {
+			return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "invalid page"})
+		}
+	}
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	onlyUnread := r.URL.Query().Get("unread") == "true"
+
+	list, count, err := s.store.GetNotifications(userID, limit, offset, onlyUnread)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the notifications: %s", err)})
+	}
+
+	for i := range list {
+		list[i].Text = notifications.Text(&list[i])
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, models.NotificationsWithPagination{
+		Notifications: list,
+		Pagination: models.Pagination{
+			TotalCount: count,
+			Page:       page,
+			Limit:      limit,
+		},
+	})
+}
+
+func (s *APIServer) handleGetUnreadNotificationsCount(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	count, err := s.store.GetUnreadNotificationsCount(userID)
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, map[string]int{
+		"unread_count": *count,
+	})
+}
+
+func (s *APIServer) handleReadNotification(w http.ResponseWriter, r *http.Request) error {
+	notificationID, err := strconv.Atoi(chi.URLParam(r, "notificationID"))
+	if err != nil {
+		return err
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	if err := s.store.ReadNotification(notificationID, userID); err != nil {
+		return utils.WriteJSON(w, http.StatusNotFound, utils.APIError{Error: fmt.Sprintf("could not read the notification: %s", err)})
+	}
+
+	s.notifier.PublishUnreadCount(userID)
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *APIServer) handleReadAllNotifications(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	if err := s.store.ReadAllNotifications(userID); err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not read the notifications: %s", err)})
+	}
+
+	s.notifier.PublishUnreadCount(userID)
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *APIServer) handleGetNotificationPreferences(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	preferences, err := s.store.GetNotificationPreferences(userID)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the notification preferences: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, preferences)
+}
+
+func (s *APIServer) handleUpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	// Only the given types and channels are changed, the rest keep their value
+	req := models.NotificationPreferences{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	defaults := models.DefaultNotificationPreferences()
+	for notificationType, channels := range req {
+		if _, ok := defaults[notificationType]; !ok {
+			return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: fmt.Sprintf("unknown notification type: %s", notificationType)})
+		}
+
+		for channel := range channels {
+			if _, ok := defaults[notificationType][channel]; !ok {
+				return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: fmt.Sprintf("unknown notification channel: %s", channel)})
+			}
+		}
+	}
+
+	if err := s.store.UpdateNotificationPreferences(userID, req); err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not update the notification preferences: %s", err)})
+	}
+
+	preferences, err := s.store.GetNotificationPreferences(userID)
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, preferences)
+}
+
+func (s *APIServer) handleGetDigestSettings(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	settings, err := s.store.GetDigestSettings(userID)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the digest settings: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, settings)
+}
+
+func (s *APIServer) handleUpdateDigestSettings(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	req := new(models.DigestSettingsReq)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	switch req.Frequency {
+	case models.DigestOff, models.DigestDaily, models.DigestWeekly:
+	default:
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "frequency must be off, daily or weekly"})
+	}
+
+	settings, err := s.store.UpdateDigestSettings(userID, req.Frequency)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not update the digest settings: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, settings)
+}
@@ -2,12 +2,14 @@ package routes
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/Marc-Garcia-Coronado/socialNetwork/middleware"
 	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/storage"
 	"github.com/Marc-Garcia-Coronado/socialNetwork/utils"
 	"github.com/go-chi/chi/v5"
 )
@@ -38,6 +40,10 @@ func (s *APIServer) handleGetUserPosts(w http.ResponseWriter, r *http.Request) e
 		return err
 	}
 
+	if ok, err := s.checkProfileAccess(w, r, paramID); !ok {
+		return err
+	}
+
 	// Get pagination query params
 	limitStr := r.URL.Query().Get("limit")
 	pageStr := r.URL.Query().Get("page")
@@ -143,9 +149,11 @@ func (s *APIServer) handleUpdatePost(w http.ResponseWriter, r *http.Request) err
 		return fmt.Errorf("no fields to update")
 	}
 
-	_, exists := post["created_at"]
-	if role != "admin" && exists {
-		return fmt.Errorf("you cannot change the created_at field")
+	// The keys become columns of the query, only these ones can be changed (pinned_at has its own endpoints)
+	for key := range post {
+		if !editablePostFields[key] && !(key == "created_at" && role == "admin") {
+			return utils.WriteJSON(w, http.StatusBadRequest, &utils.APIError{Error: fmt.Sprintf("you cannot change the %s field", key)})
+		}
 	}
 
 	updatedPost, err := s.store.UpdatePost(post, postID)
@@ -156,6 +164,13 @@ func (s *APIServer) handleUpdatePost(w http.ResponseWriter, r *http.Request) err
 	return utils.WriteJSON(w, http.StatusOK, updatedPost)
 }
 
+// editablePostFields are the fields of a post its owner can change
+var editablePostFields = map[string]bool{
+	"picture":  true,
+	"title":    true,
+	"topic_id": true,
+}
+
 func (s *APIServer) handleGetUserPostsCount(w http.ResponseWriter, r *http.Request) error {
 	userID, err := strconv.Atoi(chi.URLParam(r, "userID"))
 	if err != nil {
@@ -171,3 +186,37 @@ func (s *APIServer) handleGetUserPostsCount(w http.ResponseWriter, r *http.Reque
 		"user_posts_count": *count,
 	})
 }
+
+func (s *APIServer) handlePinPost(w http.ResponseWriter, r *http.Request) error {
+	return s.updatePostPin(w, r, s.store.PinPost, "could not pin the post")
+}
+
+func (s *APIServer) handleUnpinPost(w http.ResponseWriter, r *http.Request) error {
+	return s.updatePostPin(w, r, s.store.UnpinPost, "could not unpin the post")
+}
+
+// updatePostPin pins or unpins a post of the user of the JWT on its profile
+func (s *APIServer) updatePostPin(w http.ResponseWriter, r *http.Request, update func(userID, postID int) error, errMsg string) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	postID, err := strconv.Atoi(chi.URLParam(r, "postID"))
+	if err != nil {
+		return err
+	}
+
+	if err := update(userID, postID); err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, storage.ErrNotPostOwner):
+			status = http.StatusForbidden
+		case errors.Is(err, storage.ErrTooManyPinnedPosts):
+			status = http.StatusConflict
+		}
+		return utils.WriteJSON(w, status, utils.APIError{Error: fmt.Sprintf("%s: %s", errMsg, err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,42 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/middleware"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// handleGetProfileSummary returns the counts of the profile and the relationship of the viewer with the user in a
+// single call
+func (s *APIServer) handleGetProfileSummary(w http.ResponseWriter, r *http.Request) error {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return err
+	}
+
+	viewerID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	summary := &models.ProfileSummary{UserID: userID}
+	if userID != viewerID {
+		summary.Relationship, err = s.relationshipWith(r, viewerID, userID)
+		if err != nil {
+			return utils.WriteJSON(w, http.StatusNotFound, utils.APIError{Error: fmt.Sprintf("could not get the profile summary: %s", err)})
+		}
+	}
+
+	counts, err := s.store.GetProfileCounts(userID)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the profile summary: %s", err)})
+	}
+	summary.Counts = *counts
+
+	return utils.WriteJSON(w, http.StatusOK, summary)
+}
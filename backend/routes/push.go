@@ -0,0 +1,94 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/middleware"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/storage"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/utils"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/webpush"
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *APIServer) handleGetPushPublicKey(w http.ResponseWriter, r *http.Request) error {
+	if s.pushSender == nil {
+		return utils.WriteJSON(w, http.StatusServiceUnavailable, utils.APIError{Error: "push notifications are not available"})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"public_key": s.pushSender.PublicKey(),
+	})
+}
+
+func (s *APIServer) handleGetPushSubscriptions(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	subscriptions, err := s.store.GetPushSubscriptions(userID)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the push subscriptions: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, subscriptions)
+}
+
+func (s *APIServer) handleCreatePushSubscription(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	req := new(models.PushSubscriptionReq)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if req.Endpoint == "" || req.Keys.P256dh == "" || req.Keys.Auth == "" {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "endpoint, keys.p256dh and keys.auth are required"})
+	}
+
+	// The server posts to the endpoint on every notification, it cannot point to the server or its network
+	if _, err := utils.CheckPublicURL(req.Endpoint); err != nil {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: fmt.Sprintf("invalid endpoint: %s", err)})
+	}
+
+	if err := webpush.ValidateKeys(req.Keys.P256dh, req.Keys.Auth); err != nil {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: err.Error()})
+	}
+
+	subscription, err := s.store.SavePushSubscription(userID, req, r.UserAgent())
+	if errors.Is(err, storage.ErrPushSubscriptionTaken) {
+		return utils.WriteJSON(w, http.StatusConflict, utils.APIError{Error: fmt.Sprintf("could not save the push subscription: %s", err)})
+	}
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not save the push subscription: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusCreated, subscription)
+}
+
+func (s *APIServer) handleDeletePushSubscription(w http.ResponseWriter, r *http.Request) error {
+	subscriptionID, err := strconv.Atoi(chi.URLParam(r, "subscriptionID"))
+	if err != nil {
+		return err
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	if err := s.store.DeletePushSubscription(subscriptionID, userID); err != nil {
+		return utils.WriteJSON(w, http.StatusNotFound, utils.APIError{Error: fmt.Sprintf("could not delete the push subscription: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
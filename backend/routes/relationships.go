@@ -0,0 +1,121 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/middleware"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+// Known followers returned with the relationship, the rest are listed in /users/{id}/followers/known
+const relationshipKnownFollowers = 3
+
+func (s *APIServer) handleGetRelationship(w http.ResponseWriter, r *http.Request) error {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return err
+	}
+
+	viewerID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	if userID == viewerID {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "could not get the relationship: it is yourself"})
+	}
+
+	relationship, err := s.relationshipWith(r, viewerID, userID)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusNotFound, utils.APIError{Error: fmt.Sprintf("could not get the relationship: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, relationship)
+}
+
+// relationshipWith returns the relationship of the viewer with the user and the first known followers, the users
+// that blocked the viewer are not found unless the viewer is an admin
+func (s *APIServer) relationshipWith(r *http.Request, viewerID, userID int) (*models.Relationship, error) {
+	if _, err := s.store.GetUserByID(userID); err != nil {
+		return nil, err
+	}
+
+	relationship, err := s.store.GetRelationship(viewerID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if role, _ := r.Context().Value(middleware.UserRoleKey).(string); relationship.BlockedBy && role != "admin" {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	relationship.KnownFollowers, relationship.KnownFollowersCount, err = s.store.GetKnownFollowers(viewerID, userID, relationshipKnownFollowers, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return relationship, nil
+}
+
+func (s *APIServer) handleGetKnownFollowers(w http.ResponseWriter, r *http.Request) error {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return err
+	}
+
+	viewerID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	hidden, err := s.isHiddenFrom(r, userID)
+	if err != nil {
+		return err
+	}
+	if hidden {
+		return utils.WriteJSON(w, http.StatusNotFound, utils.APIError{Error: "user not found"})
+	}
+
+	// Get pagination query params
+	limitStr := r.URL.Query().Get("limit")
+	pageStr := r.URL.Query().Get("page")
+
+	// Set default values if params are missing
+	limit := 10 // Default limit
+	page := 1   // Default page
+
+	if limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "Invalid limit"})
+		}
+	}
+
+	if pageStr != "" {
+		page, err = strconv.Atoi(pageStr)
+		if err != nil || page <= 0 {
+			return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "Invalid page"})
+		}
+	}
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	users, count, err := s.store.GetKnownFollowers(viewerID, userID, limit, offset)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the known followers: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, models.UserWithPagination{
+		Users: users,
+		Pagination: models.Pagination{
+			Page:       page,
+			Limit:      limit,
+			TotalCount: count,
+		},
+	})
+}
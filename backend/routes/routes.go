@@ -4,9 +4,16 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/Marc-Garcia-Coronado/socialNetwork/badges"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/domain"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/jobs"
 	"github.com/Marc-Garcia-Coronado/socialNetwork/middleware"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/notifications"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/scheduler"
 	"github.com/Marc-Garcia-Coronado/socialNetwork/storage"
 	"github.com/Marc-Garcia-Coronado/socialNetwork/utils"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/webhooks"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/webpush"
 	"github.com/go-chi/chi/v5"
 	chimw "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
@@ -15,13 +22,49 @@ import (
 type APIServer struct {
 	listenAddress string
 	store         storage.Storage
+	bus           *domain.Bus
+	notifier      *notifications.Service
+	pushSender    *webpush.Sender
+	webhooks      *webhooks.Dispatcher
+	badges        *badges.Service
+	scheduler     *scheduler.Scheduler
 }
 
-func NewAPIServer(listenAddress string, store *storage.PostgresStore) *APIServer {
-	return &APIServer{
+func NewAPIServer(listenAddress string, store *storage.PostgresStore, bus *domain.Bus, queue *jobs.Queue, sched *scheduler.Scheduler) *APIServer {
+	server := &APIServer{
 		listenAddress: listenAddress,
 		store:         store,
+		bus:           bus,
+		notifier:      notifications.NewService(store),
+		webhooks:      webhooks.NewDispatcher(store, queue),
+		badges:        badges.NewService(store),
+		scheduler:     sched,
 	}
+
+	// The notifications, the webhooks and the badges react to the domain events, most of them come from the outbox
+	server.notifier.Subscribe(server.bus)
+	server.webhooks.Subscribe(server.bus)
+	server.badges.Subscribe(server.bus)
+
+	// The subscribers of the events are reminded the day before
+	sched.MustRegister("event-reminders", "*/15 * * * *", server.notifier.EventReminders)
+
+	// The rules are evaluated for everyone to award the new badges and the events already attended
+	sched.MustRegister("award-badges", "0 * * * *", server.badges.EvaluateAll)
+
+	// New notifications are pushed to the websocket connections of the user
+	server.notifier.AddPublisher(server)
+
+	// And to the devices subscribed to web push
+	sender, err := webpush.NewSenderFromEnv()
+	if err != nil {
+		log.Println("web push disabled:", err)
+	} else {
+		server.pushSender = sender
+		server.notifier.SetPusher(webpush.NewDispatcher(store, sender))
+	}
+
+	return server
 }
 
 func (s *APIServer) Run() {
@@ -40,8 +83,8 @@ func (s *APIServer) Run() {
 	router.Get("/api/topics", utils.MakeHTTPHandleFunc(s.handleGetAllTopics))
 	router.Get("/api/events/closest", utils.MakeHTTPHandleFunc(s.handleGetClosestEvents))
 	router.Get("/api/validate-token", utils.MakeHTTPHandleFunc(s.handleValidateToken))
-	// User - WebSocket route
-	router.Get("/wss", s.handleWebSocket)
+	// User - WebSocket route, the user is the one of the JWT (cookie or ?token=)
+	router.With(middleware.JWTMiddleware).Get("/wss", s.handleWebSocket)
 
 	// Protected router for not admin users
 	protectedRouter := chi.NewRouter()
@@ -61,9 +104,41 @@ func (s *APIServer) Run() {
 	protectedRouter.Get("/users/{id}/followers/count", utils.MakeHTTPHandleFunc(s.handleGetCountFollowers))
 	protectedRouter.Get("/users/{id}/follows/count", utils.MakeHTTPHandleFunc(s.handleGetUserCountFollows))
 	protectedRouter.Get("/users/{id}/following", utils.MakeHTTPHandleFunc(s.handleCheckIfFollowing))
+	protectedRouter.Get("/users/{id}/relationship", utils.MakeHTTPHandleFunc(s.handleGetRelationship))
+	protectedRouter.Get("/users/{id}/followers/known", utils.MakeHTTPHandleFunc(s.handleGetKnownFollowers))
+	protectedRouter.Get("/users/{id}/summary", utils.MakeHTTPHandleFunc(s.handleGetProfileSummary))
 	protectedRouter.Post("/users/follow/{id}", utils.MakeHTTPHandleFunc(s.handleFollowUser))
 	protectedRouter.Delete("/users/unfollow/{id}", utils.MakeHTTPHandleFunc(s.handleUnfollowUser))
 
+	// User - Follow Requests routes
+	protectedRouter.Get("/users/privacy", utils.MakeHTTPHandleFunc(s.handleGetAccountPrivacy))
+	protectedRouter.Patch("/users/privacy", utils.MakeHTTPHandleFunc(s.handleUpdateAccountPrivacy))
+	protectedRouter.Get("/follows/requests", utils.MakeHTTPHandleFunc(s.handleGetFollowRequests))
+	protectedRouter.Get("/follows/requests/sent", utils.MakeHTTPHandleFunc(s.handleGetSentFollowRequests))
+	protectedRouter.Post("/follows/requests/{userID}/approve", utils.MakeHTTPHandleFunc(s.handleApproveFollowRequest))
+	protectedRouter.Post("/follows/requests/{userID}/reject", utils.MakeHTTPHandleFunc(s.handleRejectFollowRequest))
+	protectedRouter.Delete("/follows/requests/{userID}", utils.MakeHTTPHandleFunc(s.handleCancelFollowRequest))
+
+	// User - Blocks and Mutes routes
+	protectedRouter.Get("/users/blocked", utils.MakeHTTPHandleFunc(s.handleGetBlockedUsers))
+	protectedRouter.Post("/users/{id}/block", utils.MakeHTTPHandleFunc(s.handleBlockUser))
+	protectedRouter.Delete("/users/{id}/block", utils.MakeHTTPHandleFunc(s.handleUnblockUser))
+	protectedRouter.Get("/users/muted", utils.MakeHTTPHandleFunc(s.handleGetMutedUsers))
+	protectedRouter.Post("/users/{id}/mute", utils.MakeHTTPHandleFunc(s.handleMuteUser))
+	protectedRouter.Delete("/users/{id}/mute", utils.MakeHTTPHandleFunc(s.handleUnmuteUser))
+
+	// User - Follow Suggestions routes
+	protectedRouter.Get("/users/suggestions", utils.MakeHTTPHandleFunc(s.handleGetFollowSuggestions))
+	protectedRouter.Post("/users/suggestions/{id}/dismiss", utils.MakeHTTPHandleFunc(s.handleDismissSuggestion))
+
+	// User - Badges routes
+	protectedRouter.Get("/badges", utils.MakeHTTPHandleFunc(s.handleGetBadges))
+	protectedRouter.Get("/users/{id}/badges", utils.MakeHTTPHandleFunc(s.handleGetUserBadges))
+
+	// User - Athlete Profile routes
+	protectedRouter.Get("/users/{id}/athlete-profile", utils.MakeHTTPHandleFunc(s.handleGetAthleteProfile))
+	protectedRouter.Put("/users/athlete-profile", utils.MakeHTTPHandleFunc(s.handleUpdateAthleteProfile))
+
 	// User - Topics routes
 	protectedRouter.Get("/users/{userID}/topics", utils.MakeHTTPHandleFunc(s.handleGetUserTopics))
 	protectedRouter.Get("/topics", utils.MakeHTTPHandleFunc(s.handleGetAllTopics))
@@ -79,6 +154,8 @@ func (s *APIServer) Run() {
 	protectedRouter.Post("/posts", utils.MakeHTTPHandleFunc(s.handleCreatePost))
 	protectedRouter.Patch("/users/{userID}/posts/{postID}", utils.MakeHTTPHandleFunc(s.handleUpdatePost))
 	protectedRouter.Delete("/users/{userID}/posts/{postID}", utils.MakeHTTPHandleFunc(s.handleDeletePost))
+	protectedRouter.Post("/posts/{postID}/pin", utils.MakeHTTPHandleFunc(s.handlePinPost))
+	protectedRouter.Delete("/posts/{postID}/pin", utils.MakeHTTPHandleFunc(s.handleUnpinPost))
 
 	// User - Events routes
 	protectedRouter.Get("/events", utils.MakeHTTPHandleFunc(s.handleGetAllEvents))
@@ -124,6 +201,51 @@ func (s *APIServer) Run() {
 	protectedRouter.Get("/messages/{userID}", utils.MakeHTTPHandleFunc(s.handleGetConversationMessages))
 	protectedRouter.Get("/messages/{userID}/read", utils.MakeHTTPHandleFunc(s.handleGetNotReadedConversationMessages))
 	protectedRouter.Patch("/messages/{userID}/read", utils.MakeHTTPHandleFunc(s.handleReadConversation))
+	protectedRouter.Post("/messages/{userID}/pin", utils.MakeHTTPHandleFunc(s.handlePinConversation))
+	protectedRouter.Delete("/messages/{userID}/pin", utils.MakeHTTPHandleFunc(s.handleUnpinConversation))
+	protectedRouter.Post("/messages/{userID}/archive", utils.MakeHTTPHandleFunc(s.handleArchiveConversation))
+	protectedRouter.Delete("/messages/{userID}/archive", utils.MakeHTTPHandleFunc(s.handleUnarchiveConversation))
+	protectedRouter.Post("/messages/{userID}/mute", utils.MakeHTTPHandleFunc(s.handleMuteConversation))
+	protectedRouter.Delete("/messages/{userID}/mute", utils.MakeHTTPHandleFunc(s.handleUnmuteConversation))
+	protectedRouter.Get("/messages/{userID}/retention", utils.MakeHTTPHandleFunc(s.handleGetConversationRetention))
+	protectedRouter.Put("/messages/{userID}/retention", utils.MakeHTTPHandleFunc(s.handleUpdateConversationRetention))
+
+	// User - Message Reactions routes
+	protectedRouter.Post("/messages/reactions/{messageID}", utils.MakeHTTPHandleFunc(s.handleAddMessageReaction))
+	protectedRouter.Delete("/messages/reactions/{messageID}", utils.MakeHTTPHandleFunc(s.handleRemoveMessageReaction))
+
+	// User - Encryption Keys routes
+	protectedRouter.Get("/keys/devices", utils.MakeHTTPHandleFunc(s.handleGetDevices))
+	protectedRouter.Put("/keys/devices/{deviceID}", utils.MakeHTTPHandleFunc(s.handlePublishKeys))
+	protectedRouter.Post("/keys/devices/{deviceID}/pre-keys", utils.MakeHTTPHandleFunc(s.handleUploadPreKeys))
+	protectedRouter.Delete("/keys/devices/{deviceID}", utils.MakeHTTPHandleFunc(s.handleDeleteDevice))
+	protectedRouter.Get("/users/{id}/keys", utils.MakeHTTPHandleFunc(s.handleGetKeyBundles))
+
+	// User - Message Requests routes
+	protectedRouter.Get("/messages/requests", utils.MakeHTTPHandleFunc(s.handleGetMessageRequests))
+	protectedRouter.Post("/messages/requests/{userID}/accept", utils.MakeHTTPHandleFunc(s.handleAcceptMessageRequest))
+	protectedRouter.Post("/messages/requests/{userID}/decline", utils.MakeHTTPHandleFunc(s.handleDeclineMessageRequest))
+	protectedRouter.Post("/messages/requests/{userID}/block", utils.MakeHTTPHandleFunc(s.handleBlockMessageRequest))
+	protectedRouter.Get("/messages/privacy", utils.MakeHTTPHandleFunc(s.handleGetMessagePrivacy))
+	protectedRouter.Patch("/messages/privacy", utils.MakeHTTPHandleFunc(s.handleUpdateMessagePrivacy))
+
+	// User - Notifications routes
+	protectedRouter.Get("/notifications", utils.MakeHTTPHandleFunc(s.handleGetNotifications))
+	protectedRouter.Get("/notifications/unread/count", utils.MakeHTTPHandleFunc(s.handleGetUnreadNotificationsCount))
+	protectedRouter.Patch("/notifications/read", utils.MakeHTTPHandleFunc(s.handleReadAllNotifications))
+	protectedRouter.Get("/notifications/preferences", utils.MakeHTTPHandleFunc(s.handleGetNotificationPreferences))
+	protectedRouter.Patch("/notifications/preferences", utils.MakeHTTPHandleFunc(s.handleUpdateNotificationPreferences))
+	protectedRouter.Get("/push/public-key", utils.MakeHTTPHandleFunc(s.handleGetPushPublicKey))
+	protectedRouter.Get("/push/subscriptions", utils.MakeHTTPHandleFunc(s.handleGetPushSubscriptions))
+	protectedRouter.Post("/push/subscriptions", utils.MakeHTTPHandleFunc(s.handleCreatePushSubscription))
+	protectedRouter.Delete("/push/subscriptions/{subscriptionID}", utils.MakeHTTPHandleFunc(s.handleDeletePushSubscription))
+	protectedRouter.Get("/webhooks", utils.MakeHTTPHandleFunc(userWebhooks(s.handleGetWebhooks)))
+	protectedRouter.Post("/webhooks", utils.MakeHTTPHandleFunc(userWebhooks(s.handleCreateWebhook)))
+	protectedRouter.Delete("/webhooks/{webhookID}", utils.MakeHTTPHandleFunc(userWebhooks(s.handleDeleteWebhook)))
+	protectedRouter.Get("/webhooks/{webhookID}/deliveries", utils.MakeHTTPHandleFunc(userWebhooks(s.handleGetWebhookDeliveries)))
+	protectedRouter.Get("/notifications/digest", utils.MakeHTTPHandleFunc(s.handleGetDigestSettings))
+	protectedRouter.Put("/notifications/digest", utils.MakeHTTPHandleFunc(s.handleUpdateDigestSettings))
+	protectedRouter.Patch("/notifications/{notificationID}/read", utils.MakeHTTPHandleFunc(s.handleReadNotification))
 
 	// Protected router for admin
 	adminRouter := chi.NewRouter()
@@ -139,6 +261,25 @@ func (s *APIServer) Run() {
 	adminRouter.Patch("/topics/{id}", utils.MakeHTTPHandleFunc(s.handleUpdateTopic))
 	adminRouter.Delete("/topics/{id}", utils.MakeHTTPHandleFunc(s.handleDeleteTopic))
 
+	// Admin - Badges routes
+	adminRouter.Post("/badges", utils.MakeHTTPHandleFunc(s.handleCreateBadge))
+	adminRouter.Delete("/badges/{badgeID}", utils.MakeHTTPHandleFunc(s.handleDeleteBadge))
+	adminRouter.Post("/users/{id}/badges/{badgeID}", utils.MakeHTTPHandleFunc(s.handleAwardBadge))
+	adminRouter.Delete("/users/{id}/badges/{badgeID}", utils.MakeHTTPHandleFunc(s.handleRevokeBadge))
+
+	// Admin - Webhooks routes, they receive the events of every user
+	adminRouter.Get("/webhooks", utils.MakeHTTPHandleFunc(globalWebhooks(s.handleGetWebhooks)))
+	adminRouter.Post("/webhooks", utils.MakeHTTPHandleFunc(globalWebhooks(s.handleCreateWebhook)))
+	adminRouter.Delete("/webhooks/{webhookID}", utils.MakeHTTPHandleFunc(globalWebhooks(s.handleDeleteWebhook)))
+	adminRouter.Get("/webhooks/{webhookID}/deliveries", utils.MakeHTTPHandleFunc(globalWebhooks(s.handleGetWebhookDeliveries)))
+
+	// Admin - Jobs routes
+	adminRouter.Get("/jobs", utils.MakeHTTPHandleFunc(s.handleGetJobs))
+	adminRouter.Post("/jobs/{jobID}/retry", utils.MakeHTTPHandleFunc(s.handleRetryJob))
+
+	// Admin - Scheduler routes
+	adminRouter.Get("/scheduler/tasks", utils.MakeHTTPHandleFunc(s.handleGetScheduledTasks))
+
 	// Defining the start of the url to match the patterns and then redirecting
 	// to protected router ( if it starts with /api )
 	// or admin router ( if it starts with /api/admin )
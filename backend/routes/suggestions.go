@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/middleware"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/utils"
+)
+
+func (s *APIServer) handleGetFollowSuggestions(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		return fmt.Errorf("failed to get user id from JWT")
+	}
+
+	limit := 10 // Default limit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > 50 {
+			return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "Invalid limit"})
+		}
+	}
+
+	suggestions, err := s.store.GetFollowSuggestions(userID, limit)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the suggestions: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, map[string]any{
+		"suggestions": suggestions,
+	})
+}
+
+func (s *APIServer) handleDismissSuggestion(w http.ResponseWriter, r *http.Request) error {
+	return s.updateUserRelation(w, r, s.store.DismissSuggestion, "could not dismiss the suggestion")
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 	"strconv"
+	"strings"
 
 	"github.com/Marc-Garcia-Coronado/socialNetwork/middleware"
 	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
@@ -90,6 +91,11 @@ func (s *APIServer) handleAuth(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
+	user.AthleteProfile, err = s.store.GetAthleteProfile(id)
+	if err != nil {
+		return err
+	}
+
 	return utils.WriteJSON(w, http.StatusOK, map[string]any{
 		"user": user,
 	})
@@ -107,7 +113,21 @@ func (s *APIServer) handleGetUserByID(w http.ResponseWriter, r *http.Request) er
 		return err
 	}
 
-	return utils.WriteJSON(w, http.StatusOK, user)
+	// Blocked users do not see each other profile
+	hidden, err := s.isHiddenFrom(r, user.ID)
+	if err != nil {
+		return err
+	}
+	if hidden {
+		return utils.WriteJSON(w, http.StatusNotFound, utils.APIError{Error: "user not found"})
+	}
+
+	profile, err := s.userProfile(r, user)
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, profile)
 }
 
 func (s *APIServer) handleGetUserByUserName(w http.ResponseWriter, r *http.Request) error {
@@ -119,29 +139,98 @@ func (s *APIServer) handleGetUserByUserName(w http.ResponseWriter, r *http.Reque
         return err
     }
 
-    return utils.WriteJSON(w, http.StatusOK, user)
+    // Blocked users do not see each other profile
+    hidden, err := s.isHiddenFrom(r, user.ID)
+    if err != nil {
+        return err
+    }
+    if hidden {
+        return utils.WriteJSON(w, http.StatusNotFound, utils.APIError{Error: "user not found"})
+    }
+
+    profile, err := s.userProfile(r, user)
+    if err != nil {
+        return err
+    }
+
+    return utils.WriteJSON(w, http.StatusOK, profile)
+}
+
+// userProfile returns the whole user to itself and to the admins, the rest of users only get the public profile
+func (s *APIServer) userProfile(r *http.Request, user *models.User) (any, error) {
+	athleteProfile, err := s.athleteProfileFor(r, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	badges, err := s.store.GetUserBadges(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	viewerID, _ := r.Context().Value(middleware.UserIDKey).(int)
+	role, _ := r.Context().Value(middleware.UserRoleKey).(string)
+	if viewerID == user.ID || role == "admin" {
+		user.AthleteProfile = athleteProfile
+		user.Badges = badges
+		return user, nil
+	}
+
+	public := user.Public()
+	public.AthleteProfile = athleteProfile
+	public.Badges = badges
+	return public, nil
 }
-func (s *APIServer) handleSearchUsers(w http.ResponseWriter, r *http.Request) error {
-    query := r.URL.Query().Get("query")
-    limitStr := r.URL.Query().Get("limit")
 
+func (s *APIServer) handleSearchUsers(w http.ResponseWriter, r *http.Request) error {
+    query := strings.TrimSpace(r.URL.Query().Get("query"))
     if query == "" {
-        http.Error(w, "Query parameter is required", http.StatusBadRequest)
-        return nil
+        return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "Query parameter is required"})
+    }
+
+    userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+    if !ok {
+        return fmt.Errorf("failed to get user id from JWT")
     }
 
-    limit, err := strconv.Atoi(limitStr)
-    if err != nil || limit <= 0 {
-        limit = 10 // Default limit
+    // Get pagination query params
+    limitStr := r.URL.Query().Get("limit")
+    pageStr := r.URL.Query().Get("page")
+
+    // Set default values if params are missing
+    limit := 10 // Default limit
+    page := 1   // Default page
+
+    var err error
+    if limitStr != "" {
+        limit, err = strconv.Atoi(limitStr)
+        if err != nil || limit <= 0 {
+            return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "Invalid limit"})
+        }
     }
 
-    users, err := s.store.SearchUsers(query, limit)
+    if pageStr != "" {
+        page, err = strconv.Atoi(pageStr)
+        if err != nil || page <= 0 {
+            return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "Invalid page"})
+        }
+    }
+
+    // Calculate offset
+    offset := (page - 1) * limit
+
+    users, count, err := s.store.SearchUsers(query, userID, limit, offset)
     if err != nil {
-        return err
+        return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not search the users: %s", err)})
     }
 
-    return utils.WriteJSON(w, http.StatusOK, map[string]any{
-        "users": users,
+    return utils.WriteJSON(w, http.StatusOK, models.UserWithPagination{
+        Users: users,
+        Pagination: models.Pagination{
+            Page:       page,
+            Limit:      limit,
+            TotalCount: count,
+        },
     })
 }
 
@@ -204,6 +293,11 @@ func (s *APIServer) handleUpdateUser(w http.ResponseWriter, r *http.Request) err
 		return fmt.Errorf("you cannot change the role field")
 	}
 
+	// Making the account public also approves the pending follow requests
+	if _, exists := user["is_private"]; exists {
+		return fmt.Errorf("the account privacy is changed in /users/privacy")
+	}
+
 	updatedUser, err := s.store.UpdateUser(user, id)
 	if err != nil {
 		return err
@@ -0,0 +1,175 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/middleware"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/utils"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/webhooks"
+	"github.com/go-chi/chi/v5"
+)
+
+// webhookHandler handles the webhooks of the owner, a nil owner is the global webhooks that have no user
+type webhookHandler func(w http.ResponseWriter, r *http.Request, owner *int) error
+
+// userWebhooks runs the handler on the webhooks of the user of the request
+func userWebhooks(h webhookHandler) utils.APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		userID, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+		if !ok {
+			return fmt.Errorf("failed to get user id from JWT")
+		}
+
+		return h(w, r, &userID)
+	}
+}
+
+// globalWebhooks runs the handler on the global webhooks, only the admin routes use it
+func globalWebhooks(h webhookHandler) utils.APIFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		return h(w, r, nil)
+	}
+}
+
+// validateWebhook checks the webhook before saving it, the webhooks of the users must be https. The address is
+// checked again on every delivery because the host can resolve to another address later
+func validateWebhook(req *models.WebhookReq, owner *int) error {
+	if owner != nil {
+		if _, err := utils.ParseHTTPSURL(req.URL); err != nil {
+			return err
+		}
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https url")
+	}
+
+	if len(req.Events) == 0 {
+		return fmt.Errorf("at least one event is required")
+	}
+
+	for _, event := range req.Events {
+		if !slices.Contains(models.WebhookEvents, event) {
+			return fmt.Errorf("unknown event: %s", event)
+		}
+	}
+
+	if req.TopicID != nil && !slices.ContainsFunc(req.Events, func(event string) bool {
+		return slices.Contains(models.WebhookTopicEvents, event)
+	}) {
+		return fmt.Errorf("a topic needs one of the events: %s", strings.Join(models.WebhookTopicEvents, ", "))
+	}
+
+	return nil
+}
+
+func (s *APIServer) handleCreateWebhook(w http.ResponseWriter, r *http.Request, owner *int) error {
+	req := new(models.WebhookReq)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if err := validateWebhook(req, owner); err != nil {
+		return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: err.Error()})
+	}
+
+	if req.TopicID != nil {
+		if _, err := s.store.GetTopicByID(*req.TopicID); err != nil {
+			return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "topic not found"})
+		}
+	}
+
+	secret, err := webhooks.NewID()
+	if err != nil {
+		return err
+	}
+
+	webhook, err := s.store.CreateWebhook(owner, req, secret)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not create the webhook: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusCreated, webhook)
+}
+
+func (s *APIServer) handleGetWebhooks(w http.ResponseWriter, r *http.Request, owner *int) error {
+	list, err := s.store.GetWebhooks(owner)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the webhooks: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, list)
+}
+
+func (s *APIServer) handleDeleteWebhook(w http.ResponseWriter, r *http.Request, owner *int) error {
+	webhookID, err := strconv.Atoi(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.DeleteWebhook(webhookID, owner); err != nil {
+		return utils.WriteJSON(w, http.StatusNotFound, utils.APIError{Error: fmt.Sprintf("could not delete the webhook: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+func (s *APIServer) handleGetWebhookDeliveries(w http.ResponseWriter, r *http.Request, owner *int) error {
+	webhookID, err := strconv.Atoi(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		return err
+	}
+
+	// Only the owner of the webhook can see its deliveries
+	if _, err := s.store.GetWebhookByID(webhookID, owner); err != nil {
+		return utils.WriteJSON(w, http.StatusNotFound, utils.APIError{Error: "webhook not found"})
+	}
+
+	// Get pagination query params
+	limitStr := r.URL.Query().Get("limit")
+	pageStr := r.URL.Query().Get("page")
+
+	// Set default values if params are missing
+	limit := 10 // Default limit
+	page := 1   // Default page
+
+	if limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "invalid limit"})
+		}
+	}
+
+	if pageStr != "" {
+		page, err = strconv.Atoi(pageStr)
+		if err != nil || page <= 0 {
+			return utils.WriteJSON(w, http.StatusBadRequest, utils.APIError{Error: "invalid page"})
+		}
+	}
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	deliveries, count, err := s.store.GetWebhookDeliveries(webhookID, limit, offset)
+	if err != nil {
+		return utils.WriteJSON(w, http.StatusInternalServerError, utils.APIError{Error: fmt.Sprintf("could not get the deliveries: %s", err)})
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, models.WebhookDeliveriesWithPagination{
+		Deliveries: deliveries,
+		Pagination: models.Pagination{
+			TotalCount: count,
+			Page:       page,
+			Limit:      limit,
+		},
+	})
+}
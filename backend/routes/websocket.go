@@ -1,15 +1,99 @@
 package routes
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
+	"sync"
+	"time"
 
+	"github.com/Marc-Garcia-Coronado/socialNetwork/domain"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/middleware"
 	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/storage"
 	"github.com/gorilla/websocket"
 )
 
-var clients = make(map[string]*websocket.Conn)
+// Tiempo máximo para escribir en una conexión, una conexión lenta no bloquea al resto
+const writeWait = 10 * time.Second
+
+// client es una conexión abierta, un websocket no admite escrituras concurrentes así que
+// cada conexión serializa las suyas con su propio mutex
+type client struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+}
+
+func (c *client) writeJSON(v any) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteJSON(v)
+}
+
+// sendError devuelve el error de un mensaje solo a la conexión que lo envió, las demás pestañas no lo ven
+func (c *client) sendError(msg string) {
+	if err := c.writeJSON(models.WSEvent{Type: "error", Data: msg}); err != nil {
+		log.Println("Error al enviar el error al cliente:", err)
+		// La lectura falla al cerrarla y el manejador termina
+		c.conn.Close()
+	}
+}
+
+// Un usuario puede tener varias conexiones abiertas (pestañas, dispositivos)
+var (
+	clients   = make(map[int]map[*client]bool)
+	clientsMu sync.Mutex
+)
+
+func addClient(userID int, c *client) {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+
+	if clients[userID] == nil {
+		clients[userID] = make(map[*client]bool)
+	}
+	clients[userID][c] = true
+}
+
+func removeClient(userID int, c *client) {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+
+	delete(clients[userID], c)
+	if len(clients[userID]) == 0 {
+		delete(clients, userID)
+	}
+}
+
+// isOnline indica si el usuario tiene alguna conexión abierta
+func isOnline(userID int) bool {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+
+	return len(clients[userID]) > 0
+}
+
+// sendToUser envía el valor a todas las conexiones del usuario, se escribe fuera del lock global
+// para que una conexión lenta no bloquee los envíos a los demás usuarios
+func sendToUser(userID int, v any) {
+	clientsMu.Lock()
+	conns := make([]*client, 0, len(clients[userID]))
+	for c := range clients[userID] {
+		conns = append(conns, c)
+	}
+	clientsMu.Unlock()
+
+	for _, c := range conns {
+		if err := c.writeJSON(v); err != nil {
+			log.Println("Error al enviar al usuario", userID, ":", err)
+			// La lectura de la conexión falla al cerrarla y el manejador la quita de la lista
+			c.conn.Close()
+		}
+	}
+}
 
 // Upgrader para WebSocket
 var upgrader = websocket.Upgrader{
@@ -21,9 +105,10 @@ var upgrader = websocket.Upgrader{
 }
 
 func (s *APIServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
-	userID := r.URL.Query().Get("userID")
-	if userID == "" {
-		http.Error(w, "Missing userID query parameter", http.StatusBadRequest)
+	// Las notificaciones y los mensajes se entregan a las conexiones del usuario del JWT
+	sender, ok := r.Context().Value(middleware.UserIDKey).(int) // Get the user id from the JWT
+	if !ok {
+		http.Error(w, "failed to get user id from JWT", http.StatusUnauthorized)
 		return
 	}
 
@@ -34,51 +119,111 @@ func (s *APIServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 	defer conn.Close()
 
-	clients[userID] = conn
-	log.Println("Usuario conectado:", userID)
+	c := &client{conn: conn}
+	addClient(sender, c)
+	defer removeClient(sender, c)
+	log.Println("Usuario conectado:", sender)
 
 	for {
-		var msg map[string]string
+		var msg models.WSMessageReq
 		if err := conn.ReadJSON(&msg); err != nil {
 			log.Println("Error al leer mensaje:", err)
-			delete(clients, userID)
 			break
 		}
 
-		to := msg["to"]
-		content := msg["content"]
+		to := msg.To
+		content := msg.Content
+
+		// El contenido de los mensajes cifrados no se registra ni se inspecciona
+		log.Printf("Mensaje de %d a %s (%s)\n", sender, to, msg.Mode)
 
-		log.Printf("Mensaje de %s a %s: %s\n", userID, to, content)
+		if err := validateMessageMode(&msg); err != nil {
+			c.sendError(err.Error())
+			continue
+		}
 
+		// Los errores de un mensaje se devuelven a la conexión que lo envió sin cerrarla
 		reciever, err := strconv.Atoi(to)
 		if err != nil {
-			return
+			c.sendError("invalid receiver id")
+			continue
 		}
-		sender, err := strconv.Atoi(userID)
+
+		delivery, err := s.store.GetMessageDelivery(sender, reciever)
 		if err != nil {
-			return
+			c.sendError(err.Error())
+			continue
+		}
+
+		// El destinatario no acepta mensajes de este usuario
+		if delivery == models.MessageDeliveryDenied {
+			c.sendError("this user does not accept your messages")
+			continue
 		}
 
 		newMessage := new(models.MessageReq)
 		newMessage.Content = content
 		newMessage.ReceiverID = reciever
 		newMessage.SenderID = sender
+		newMessage.Mode = msg.Mode
+		newMessage.Ciphertexts = msg.Ciphertexts
 
 		newMsg, err := s.store.SaveMessage(newMessage)
 		if err != nil {
-			return
+			if newMessage.Mode == models.MessageModeE2E || errors.Is(err, storage.ErrBlocked) {
+				c.sendError(err.Error())
+				continue
+			}
+			log.Println("Error al guardar el mensaje:", err)
+			c.sendError("could not save the message")
+			continue
 		}
 
-		// Enviar al destinatario si está conectado
-		if toConn, ok := clients[to]; ok {
-			toConn.WriteJSON(newMsg)
+		if delivery == models.MessageDeliveryRequest {
+			if err := s.store.CreateMessageRequest(sender, reciever); err != nil {
+				log.Println("Error al crear la solicitud de mensaje:", err)
+				c.sendError("could not create the message request")
+				continue
+			}
 		}
 
-		// También enviar al emisor (si está conectado)
-		if fromConn, ok := clients[userID]; ok {
-			fromConn.WriteJSON(newMsg)
+		// Enviar al destinatario si está conectado, las solicitudes van a su bandeja aparte
+		isRequest := delivery == models.MessageDeliveryRequest
+		if isRequest {
+			sendToUser(reciever, models.WSEvent{Type: "message_request", Data: newMsg})
+		} else {
+			sendToUser(reciever, newMsg)
 		}
+
+		// Depende de las conexiones abiertas, por eso se publica directamente y no pasa por el outbox
+		if err := s.bus.Publish(domain.MessageSent{Base: domain.NewBase(), Message: *newMsg, ReceiverOnline: isOnline(reciever), IsRequest: isRequest}); err != nil {
+			log.Println("Error al notificar el mensaje:", err)
+		}
+
+		// También enviar al emisor (si está conectado)
+		sendToUser(sender, newMsg)
 	}
 
 	return
 }
+
+// PublishNotification entrega la notificación y el nuevo contador en tiempo real
+func (s *APIServer) PublishNotification(userID int, notification *models.Notification, unreadCount int) {
+	sendToUser(userID, models.WSEvent{
+		Type: "notification",
+		Data: models.NotificationEvent{
+			Notification: notification,
+			UnreadCount:  unreadCount,
+		},
+	})
+}
+
+// PublishUnreadCount sincroniza el contador de todas las conexiones, por ejemplo al leer desde otra pestaña
+func (s *APIServer) PublishUnreadCount(userID int, unreadCount int) {
+	sendToUser(userID, models.WSEvent{
+		Type: "notifications_unread_count",
+		Data: models.NotificationEvent{
+			UnreadCount: unreadCount,
+		},
+	})
+}
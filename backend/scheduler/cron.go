@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression with the five standard fields:
+// minute hour day-of-month month day-of-week
+type Schedule struct {
+	expr                          string
+	minute, hour, dom, month, dow uint64
+	domRestricted, dowRestricted  bool
+}
+
+var fieldBounds = []struct{ min, max int }{
+	{0, 59}, // minute
+	{0, 23}, // hour
+	{1, 31}, // day of month
+	{1, 12}, // month
+	{0, 6},  // day of week, sunday is 0 (7 is accepted too)
+}
+
+// Parse accepts "*", numbers, ranges "1-5", lists "1,3" and steps "*/15" or "0-30/10"
+func Parse(expr string) (*Schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields", expr)
+	}
+
+	var sets [5]uint64
+	for i, field := range fields {
+		bounds := fieldBounds[i]
+		max := bounds.max
+		if i == 4 {
+			max = 7
+		}
+
+		set, err := parseField(field, bounds.min, max)
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", expr, err)
+		}
+		sets[i] = set
+	}
+
+	// Sunday can be written as 0 or 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] = sets[4]&^(1<<7) | 1
+	}
+
+	return &Schedule{
+		expr:          expr,
+		minute:        sets[0],
+		hour:          sets[1],
+		dom:           sets[2],
+		month:         sets[3],
+		dow:           sets[4],
+		domRestricted: fields[2] != "*",
+		dowRestricted: fields[4] != "*",
+	}, nil
+}
+
+func parseField(field string, min, max int) (uint64, error) {
+	var set uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+		}
+
+		start, end := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err1, err2 error
+			start, err1 = strconv.Atoi(from)
+			end, err2 = strconv.Atoi(to)
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		default:
+			value, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", rangePart)
+			}
+			start = value
+			// "5/10" means from 5 to the end every 10
+			if !hasStep {
+				end = value
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return 0, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+
+		for v := start; v <= end; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+
+	return set, nil
+}
+
+// Matches tells if the schedule runs at the minute of t
+func (s *Schedule) Matches(t time.Time) bool {
+	if s.minute&(1<<uint(t.Minute())) == 0 || s.hour&(1<<uint(t.Hour())) == 0 || s.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	// Like in cron, when both days are restricted either of them is enough
+	if s.domRestricted && s.dowRestricted {
+		return domMatch || dowMatch
+	}
+
+	return domMatch && dowMatch
+}
+
+// Next returns the first minute after t when the schedule runs, the zero time if there is none in 5 years
+func (s *Schedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := next.AddDate(5, 0, 0)
+
+	for next.Before(limit) {
+		if s.Matches(next) {
+			return next
+		}
+		next = next.Add(time.Minute)
+	}
+
+	return time.Time{}
+}
+
+func (s *Schedule) String() string {
+	return s.expr
+}
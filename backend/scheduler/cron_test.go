@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) should fail", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// Wednesday
+	from := time.Date(2025, time.March, 5, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, time.March, 5, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, time.March, 5, 10, 15, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2025, time.March, 6, 3, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1", time.Date(2025, time.March, 10, 9, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, time.March, 9, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2025, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1,15 * *", time.Date(2025, time.March, 15, 12, 0, 0, 0, time.UTC)},
+		{"5-10/5 10 * * *", time.Date(2025, time.March, 5, 10, 10, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		schedule, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %s", tt.expr, err)
+		}
+
+		if got := schedule.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+}
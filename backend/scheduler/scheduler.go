@@ -0,0 +1,126 @@
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/storage"
+)
+
+type task struct {
+	name     string
+	schedule *Schedule
+	run      func(now time.Time) error
+}
+
+// Scheduler runs the registered tasks on their cron schedule. Every instance of the backend
+// ticks, but for each run only the one that takes the advisory lock of the task runs it
+type Scheduler struct {
+	store storage.Storage
+	mu    sync.RWMutex
+	tasks []task
+}
+
+func New(store storage.Storage) *Scheduler {
+	return &Scheduler{
+		store: store,
+	}
+}
+
+// Register adds a task, the names identify the tasks across instances so they must be unique
+func (s *Scheduler) Register(name, expr string, run func(now time.Time) error) error {
+	schedule, err := Parse(expr)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.tasks {
+		if t.name == name {
+			return fmt.Errorf("task %s is already registered", name)
+		}
+	}
+
+	s.tasks = append(s.tasks, task{name: name, schedule: schedule, run: run})
+	return nil
+}
+
+// MustRegister is Register for the tasks of the application, which are known to be valid
+func (s *Scheduler) MustRegister(name, expr string, run func(now time.Time) error) {
+	if err := s.Register(name, expr, run); err != nil {
+		panic(err)
+	}
+}
+
+// Start checks the schedules at the beginning of every minute
+func (s *Scheduler) Start() {
+	go func() {
+		for {
+			now := time.Now()
+			next := now.Truncate(time.Minute).Add(time.Minute)
+			time.Sleep(next.Sub(now))
+
+			s.tick(next)
+		}
+	}()
+}
+
+func (s *Scheduler) tick(slot time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, t := range s.tasks {
+		if t.schedule.Matches(slot) {
+			go s.runTask(t, slot)
+		}
+	}
+}
+
+func (s *Scheduler) runTask(t task, slot time.Time) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("scheduler: task %s panicked: %v\n", t.name, r)
+		}
+	}()
+
+	_, err := s.store.RunScheduledTask(t.name, slot, func() error {
+		return t.run(slot)
+	})
+	if err != nil {
+		log.Printf("scheduler: task %s failed: %s\n", t.name, err)
+	}
+}
+
+// Tasks returns the registered tasks with their next run and the result of their last run
+func (s *Scheduler) Tasks() ([]models.ScheduledTask, error) {
+	runs, err := s.store.GetScheduledTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	lastRuns := make(map[string]models.ScheduledTask, len(runs))
+	for _, run := range runs {
+		lastRuns[run.Name] = run
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	tasks := make([]models.ScheduledTask, 0, len(s.tasks))
+	for _, t := range s.tasks {
+		task := lastRuns[t.name]
+		task.Name = t.name
+		task.Schedule = t.schedule.String()
+		task.NextRunAt = t.schedule.Next(now).Format(time.RFC3339)
+
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/lib/pq"
+)
+
+// GetAthleteProfile returns the whole athlete profile of the user, users without one get an empty profile
+func (s *PostgresStore) GetAthleteProfile(userID int) (*models.AthleteProfile, error) {
+	stmt := `
+	SELECT sports, home_city, training_times, height_cm, weight_kg, links, visibility
+	FROM athlete_profiles
+	WHERE user_id = $1;
+	`
+
+	profile := &models.AthleteProfile{Visibility: models.DefaultProfileVisibility()}
+
+	var sports, links, visibility []byte
+	err := s.Db.QueryRow(stmt, userID).Scan(&sports, &profile.HomeCity, pq.Array(&profile.TrainingTimes),
+		&profile.HeightCm, &profile.WeightKg, &links, &visibility)
+	if err == sql.ErrNoRows {
+		return profile, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(sports, &profile.Sports); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(links, &profile.Links); err != nil {
+		return nil, err
+	}
+
+	var changed models.ProfileVisibility
+	if err := json.Unmarshal(visibility, &changed); err != nil {
+		return nil, err
+	}
+	for field, value := range changed {
+		profile.Visibility[field] = value
+	}
+
+	return profile, nil
+}
+
+// UpdateAthleteProfile replaces the athlete profile of the user, only the given visibilities are changed
+func (s *PostgresStore) UpdateAthleteProfile(userID int, req *models.AthleteProfileReq) (*models.AthleteProfile, error) {
+	stmt := `
+	INSERT INTO athlete_profiles (user_id, sports, home_city, training_times, height_cm, weight_kg, links, visibility)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (user_id) DO UPDATE
+	SET sports = EXCLUDED.sports, home_city = EXCLUDED.home_city, training_times = EXCLUDED.training_times,
+	    height_cm = EXCLUDED.height_cm, weight_kg = EXCLUDED.weight_kg, links = EXCLUDED.links,
+	    visibility = athlete_profiles.visibility || EXCLUDED.visibility, updated_at = now();
+	`
+
+	if req.Sports == nil {
+		req.Sports = []models.ProfileSport{}
+	}
+	if req.TrainingTimes == nil {
+		req.TrainingTimes = []string{}
+	}
+	if req.Links == nil {
+		req.Links = []models.ProfileLink{}
+	}
+	if req.Visibility == nil {
+		req.Visibility = models.ProfileVisibility{}
+	}
+
+	sports, err := json.Marshal(req.Sports)
+	if err != nil {
+		return nil, err
+	}
+	links, err := json.Marshal(req.Links)
+	if err != nil {
+		return nil, err
+	}
+	visibility, err := json.Marshal(req.Visibility)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.Db.Exec(stmt, userID, sports, req.HomeCity, pq.Array(req.TrainingTimes), req.HeightCm, req.WeightKg, links, visibility)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetAthleteProfile(userID)
+}
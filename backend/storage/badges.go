@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+)
+
+func (s *PostgresStore) CreateBadge(badge *models.BadgeReq) (*models.Badge, error) {
+	stmt := `
+	INSERT INTO badges (name, description, icon, rule, threshold)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING id, name, description, icon, rule, threshold, created_at;
+	`
+
+	newBadge := new(models.Badge)
+	err := s.Db.QueryRow(stmt, badge.Name, badge.Description, badge.Icon, badge.Rule, badge.Threshold).Scan(
+		&newBadge.ID, &newBadge.Name, &newBadge.Description, &newBadge.Icon, &newBadge.Rule, &newBadge.Threshold, &newBadge.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return newBadge, nil
+}
+
+func (s *PostgresStore) GetBadges() ([]models.Badge, error) {
+	stmt := `
+	SELECT id, name, description, icon, rule, threshold, created_at
+	FROM badges
+	ORDER BY id;
+	`
+
+	rows, err := s.Db.Query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	badges := []models.Badge{}
+	for rows.Next() {
+		var badge models.Badge
+		if err := rows.Scan(&badge.ID, &badge.Name, &badge.Description, &badge.Icon, &badge.Rule, &badge.Threshold, &badge.CreatedAt); err != nil {
+			return nil, err
+		}
+		badges = append(badges, badge)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return badges, nil
+}
+
+// DeleteBadge removes the badge and takes it from every user that had it
+func (s *PostgresStore) DeleteBadge(id int) error {
+	res, err := s.Db.Exec("DELETE FROM badges WHERE id = $1;", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("no badge found to delete")
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) GetUserBadges(userID int) ([]models.UserBadge, error) {
+	stmt := `
+	SELECT b.id, b.name, b.description, b.icon, b.rule, b.threshold, b.created_at, ub.awarded_at, ub.awarded_by
+	FROM user_badges ub
+	JOIN badges b ON b.id = ub.badge_id
+	WHERE ub.user_id = $1 AND ub.revoked_at IS NULL
+	ORDER BY ub.awarded_at DESC;
+	`
+
+	rows, err := s.Db.Query(stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	badges := []models.UserBadge{}
+	for rows.Next() {
+		var badge models.UserBadge
+		if err := rows.Scan(&badge.ID, &badge.Name, &badge.Description, &badge.Icon, &badge.Rule, &badge.Threshold,
+			&badge.CreatedAt, &badge.AwardedAt, &badge.AwardedBy); err != nil {
+			return nil, err
+		}
+		badges = append(badges, badge)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return badges, nil
+}
+
+// AwardBadge gives a badge to a user by hand, awarding a badge the user already has does nothing and a
+// revoked badge is given back only this way
+func (s *PostgresStore) AwardBadge(userID, badgeID, adminID int) error {
+	stmt := `
+	INSERT INTO user_badges (user_id, badge_id, awarded_by)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (user_id, badge_id) DO UPDATE
+	SET revoked_at = null, awarded_by = EXCLUDED.awarded_by, awarded_at = now()
+	WHERE user_badges.revoked_at IS NOT NULL;
+	`
+
+	_, err := s.Db.Exec(stmt, userID, badgeID, adminID)
+	return err
+}
+
+// RevokeBadge takes the badge from the user, the revocation is recorded so the rules do not award it again
+func (s *PostgresStore) RevokeBadge(userID, badgeID int) error {
+	stmt := "UPDATE user_badges SET revoked_at = now() WHERE user_id = $1 AND badge_id = $2 AND revoked_at IS NULL;"
+
+	res, err := s.Db.Exec(stmt, userID, badgeID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("no awarded badge found to revoke")
+	}
+
+	return nil
+}
+
+// awardRuleBadgesStmt gives the badges with a rule to the users matching the condition whose activity reached the
+// threshold. Revoked badges keep their row, so they are not awarded again
+const awardRuleBadgesStmt = `
+	INSERT INTO user_badges (user_id, badge_id)
+	SELECT u.id, b.id
+	FROM users u
+	CROSS JOIN badges b
+	WHERE %s AND b.rule IS NOT NULL
+	AND NOT EXISTS (SELECT 1 FROM user_badges ub WHERE ub.user_id = u.id AND ub.badge_id = b.id)
+	AND b.threshold <= CASE b.rule
+		WHEN 'posts' THEN (SELECT count(*) FROM posts p WHERE p.user_id = u.id)
+		WHEN 'events_organised' THEN (SELECT count(*) FROM events e WHERE e.creator_id = u.id)
+		WHEN 'events_attended' THEN (
+			SELECT count(*) FROM user_event ue
+			JOIN events e ON e.id = ue.event_id
+			WHERE ue.user_id = u.id AND e.date < now()
+		)
+		WHEN 'likes_received' THEN (
+			SELECT count(*) FROM likes l
+			JOIN posts p ON p.id = l.post_id
+			WHERE p.user_id = u.id
+		)
+		WHEN 'followers' THEN (SELECT count(*) FROM user_follow_user f WHERE f.user_followed_id = u.id)
+	END
+	ON CONFLICT (user_id, badge_id) DO NOTHING;`
+
+// AwardRuleBadges gives the user the badges whose rules it meets and returns how many were awarded
+func (s *PostgresStore) AwardRuleBadges(userID int) (int64, error) {
+	return s.awardRuleBadges(fmt.Sprintf(awardRuleBadgesStmt, "u.id = $1"), userID)
+}
+
+// AwardAllRuleBadges evaluates the rules for every active user, the badges created after the activity and the
+// events that already happened are awarded this way
+func (s *PostgresStore) AwardAllRuleBadges() (int64, error) {
+	return s.awardRuleBadges(fmt.Sprintf(awardRuleBadgesStmt, "u.is_active"))
+}
+
+func (s *PostgresStore) awardRuleBadges(stmt string, args ...any) (int64, error) {
+	res, err := s.Db.Exec(stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"errors"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+)
+
+// ErrBlocked is returned when an action involves two users and one of them blocked the other
+var ErrBlocked = errors.New("this user is not available")
+
+// BlockUser blocks the user in both directions, the follows and follow requests between them are removed
+func (s *PostgresStore) BlockUser(userID, blockedID int) error {
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt := `
+	INSERT INTO user_blocks (blocker_id, blocked_id)
+	VALUES ($1, $2)
+	ON CONFLICT (blocker_id, blocked_id) DO NOTHING;
+	`
+
+	if _, err := tx.Exec(stmt, userID, blockedID); err != nil {
+		return err
+	}
+
+	stmtFollows := `
+	DELETE FROM user_follow_user
+	WHERE (user_following_id = $1 AND user_followed_id = $2) OR (user_following_id = $2 AND user_followed_id = $1);
+	`
+
+	if _, err := tx.Exec(stmtFollows, userID, blockedID); err != nil {
+		return err
+	}
+
+	stmtRequests := `
+	DELETE FROM follow_requests
+	WHERE (requester_id = $1 AND target_id = $2) OR (requester_id = $2 AND target_id = $1);
+	`
+
+	if _, err := tx.Exec(stmtRequests, userID, blockedID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgresStore) UnblockUser(userID, blockedID int) error {
+	res, err := s.Db.Exec("DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2;", userID, blockedID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("no block found to delete")
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) GetBlockedUsers(userID int) ([]models.PublicUser, error) {
+	stmt := `
+	SELECT u.id, u.user_name, u.full_name, u.profile_picture, u.role
+	FROM user_blocks b
+	JOIN users u ON u.id = b.blocked_id
+	WHERE b.blocker_id = $1
+	ORDER BY b.created_at DESC;
+	`
+
+	return s.getRelatedUsers(stmt, userID)
+}
+
+// IsBlocked tells if any of the two users blocked the other
+func (s *PostgresStore) IsBlocked(userID, otherUserID int) (bool, error) {
+	stmt := `
+	SELECT EXISTS (
+		SELECT 1 FROM user_blocks
+		WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+	);
+	`
+
+	var blocked bool
+	if err := s.Db.QueryRow(stmt, userID, otherUserID).Scan(&blocked); err != nil {
+		return false, err
+	}
+
+	return blocked, nil
+}
+
+// MuteUser hides the posts and comments of the muted user from the feed of the user, the muted user is not told
+func (s *PostgresStore) MuteUser(userID, mutedID int) error {
+	stmt := `
+	INSERT INTO user_mutes (user_id, muted_user_id)
+	VALUES ($1, $2)
+	ON CONFLICT (user_id, muted_user_id) DO NOTHING;
+	`
+
+	_, err := s.Db.Exec(stmt, userID, mutedID)
+	return err
+}
+
+func (s *PostgresStore) UnmuteUser(userID, mutedID int) error {
+	res, err := s.Db.Exec("DELETE FROM user_mutes WHERE user_id = $1 AND muted_user_id = $2;", userID, mutedID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("no mute found to delete")
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) GetMutedUsers(userID int) ([]models.PublicUser, error) {
+	stmt := `
+	SELECT u.id, u.user_name, u.full_name, u.profile_picture, u.role
+	FROM user_mutes m
+	JOIN users u ON u.id = m.muted_user_id
+	WHERE m.user_id = $1
+	ORDER BY m.created_at DESC;
+	`
+
+	return s.getRelatedUsers(stmt, userID)
+}
+
+func (s *PostgresStore) getRelatedUsers(stmt string, userID int) ([]models.PublicUser, error) {
+	rows, err := s.Db.Query(stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.PublicUser
+	for rows.Next() {
+		var user models.PublicUser
+		if err := rows.Scan(&user.ID, &user.UserName, &user.FullName, &user.ProfilePicture, &user.Role); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// checkNotBlocked runs a query that tells if there is a block and turns it into ErrBlocked
+func checkNotBlocked(q queryRower, stmt string, args ...any) error {
+	var blocked bool
+	if err := q.QueryRow(stmt, args...).Scan(&blocked); err != nil {
+		return err
+	}
+
+	if blocked {
+		return ErrBlocked
+	}
+
+	return nil
+}
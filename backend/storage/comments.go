@@ -3,6 +3,7 @@ package storage
 import (
 	"errors"
 
+	"github.com/Marc-Garcia-Coronado/socialNetwork/domain"
 	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
 )
 
@@ -14,9 +15,9 @@ func (s *PostgresStore) CreateComment(comment *models.CommentReq) (*models.Comme
 		RETURNING *
 	)
 	SELECT c.id, c.body, c.created_at,
-	       u.id AS user_id, u.user_name, u.full_name, u.email, u.profile_picture, u.bio, u.is_active, u.role,
+	       u.id AS user_id, u.user_name, u.full_name, u.profile_picture, u.bio, u.is_active, u.role,
 	       p.id AS post_id, p.picture, p.title, p.created_at AS post_created_at,
-	       up.id AS post_user_id, up.user_name AS post_user_name, up.full_name AS post_full_name, up.email AS post_user_email, up.profile_picture AS post_user_profile_picture,
+	       up.id AS post_user_id, up.user_name AS post_user_name, up.full_name AS post_full_name, up.profile_picture AS post_user_profile_picture,
 	       up.bio AS post_user_bio, up.is_active AS post_user_is_active, up.role AS post_user_role,
 	       t.id AS topic_id, t.name AS topic_name, t.description AS topic_description, t.created_at AS topic_created_at
 	FROM inserted_comment c
@@ -26,13 +27,23 @@ func (s *PostgresStore) CreateComment(comment *models.CommentReq) (*models.Comme
 	LEFT JOIN topics t ON t.id = p.topic_id;
 	`
 
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkNotBlocked(tx, stmtPostOwnerBlocked, comment.UserID, comment.PostID); err != nil {
+		return nil, err
+	}
+
 	newComment := new(models.Comment)
-	err := s.Db.QueryRow(stmt, comment.Body, comment.UserID, comment.PostID).Scan(
+	err = tx.QueryRow(stmt, comment.Body, comment.UserID, comment.PostID).Scan(
 		&newComment.ID, &newComment.Body, &newComment.CreatedAt,
-		&newComment.User.ID, &newComment.User.UserName, &newComment.User.FullName, &newComment.User.Email, &newComment.User.ProfilePicture,
+		&newComment.User.ID, &newComment.User.UserName, &newComment.User.FullName, &newComment.User.ProfilePicture,
 		&newComment.User.Bio, &newComment.User.IsActive, &newComment.User.Role,
 		&newComment.Post.ID, &newComment.Post.Picture, &newComment.Post.Title, &newComment.Post.CreatedAt,
-		&newComment.Post.User.ID, &newComment.Post.User.UserName, &newComment.Post.User.FullName, &newComment.Post.User.Email, &newComment.Post.User.ProfilePicture,
+		&newComment.Post.User.ID, &newComment.Post.User.UserName, &newComment.Post.User.FullName, &newComment.Post.User.ProfilePicture,
 		&newComment.Post.User.Bio, &newComment.Post.User.IsActive, &newComment.Post.User.Role,
 		&newComment.Post.Topic.ID, &newComment.Post.Topic.Name, &newComment.Post.Topic.Description, &newComment.Post.Topic.CreatedAt,
 	)
@@ -40,21 +51,38 @@ func (s *PostgresStore) CreateComment(comment *models.CommentReq) (*models.Comme
 		return nil, err
 	}
 
+	event := domain.CommentCreated{Base: domain.NewBase(), Comment: *newComment, UserID: comment.UserID, PostID: comment.PostID}
+	if err := insertOutboxEvent(tx, event); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	return newComment, nil
 }
 
-func (s *PostgresStore) GetPostComments(postID, limit, offset int) ([]models.Comment, int, error) {
+// GetPostComments returns the comments of the post that the viewer can see, the ones of blocked and muted users are hidden
+func (s *PostgresStore) GetPostComments(postID, viewerID, limit, offset int) ([]models.Comment, int, error) {
 	var totalCount int
-	queryCount := "SELECT COUNT(*) FROM comments WHERE post_id = $1;"
-	if err := s.Db.QueryRow(queryCount, postID).Scan(&totalCount); err != nil {
+	queryCount := `
+	SELECT COUNT(*) FROM comments c
+	WHERE c.post_id = $1
+	AND NOT EXISTS (
+		SELECT 1 FROM user_blocks b
+		WHERE (b.blocker_id = $2 AND b.blocked_id = c.user_id) OR (b.blocker_id = c.user_id AND b.blocked_id = $2)
+	)
+	AND NOT EXISTS (SELECT 1 FROM user_mutes m WHERE m.user_id = $2 AND m.muted_user_id = c.user_id);`
+	if err := s.Db.QueryRow(queryCount, postID, viewerID).Scan(&totalCount); err != nil {
 		return nil, 0, err
 	}
 
 	stmt := `
 	SELECT c.id, c.body, c.created_at,
-	       u.id AS user_id, u.user_name, u.full_name, u.email, u.profile_picture, u.bio, u.is_active, u.role,
+	       u.id AS user_id, u.user_name, u.full_name, u.profile_picture, u.bio, u.is_active, u.role,
 	       p.id AS post_id, p.picture, p.title, p.created_at AS post_created_at,
-	       up.id AS post_user_id, up.user_name AS post_user_name, up.full_name AS post_full_name, up.email AS post_user_email, up.profile_picture AS post_user_profile_picture,
+	       up.id AS post_user_id, up.user_name AS post_user_name, up.full_name AS post_full_name, up.profile_picture AS post_user_profile_picture,
 	       up.bio AS post_user_bio, up.is_active AS post_user_is_active, up.role AS post_user_role,
 	       t.id AS topic_id, t.name AS topic_name, t.description AS topic_description, t.created_at AS topic_created_at
 	FROM comments c
@@ -63,11 +91,16 @@ func (s *PostgresStore) GetPostComments(postID, limit, offset int) ([]models.Com
 	JOIN users up ON up.id = p.user_id
 	LEFT JOIN topics t ON t.id = p.topic_id
 	WHERE c.post_id = $1
+	AND NOT EXISTS (
+		SELECT 1 FROM user_blocks b
+		WHERE (b.blocker_id = $2 AND b.blocked_id = c.user_id) OR (b.blocker_id = c.user_id AND b.blocked_id = $2)
+	)
+	AND NOT EXISTS (SELECT 1 FROM user_mutes m WHERE m.user_id = $2 AND m.muted_user_id = c.user_id)
 	ORDER BY c.created_at DESC
-	LIMIT $2 OFFSET $3;
+	LIMIT $3 OFFSET $4;
 	`
 
-	rows, err := s.Db.Query(stmt, postID, limit, offset)
+	rows, err := s.Db.Query(stmt, postID, viewerID, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -78,10 +111,10 @@ func (s *PostgresStore) GetPostComments(postID, limit, offset int) ([]models.Com
 		newComment := new(models.Comment)
 		if err := rows.Scan(
 			&newComment.ID, &newComment.Body, &newComment.CreatedAt,
-			&newComment.User.ID, &newComment.User.UserName, &newComment.User.FullName, &newComment.User.Email, &newComment.User.ProfilePicture,
+			&newComment.User.ID, &newComment.User.UserName, &newComment.User.FullName, &newComment.User.ProfilePicture,
 			&newComment.User.Bio, &newComment.User.IsActive, &newComment.User.Role,
 			&newComment.Post.ID, &newComment.Post.Picture, &newComment.Post.Title, &newComment.Post.CreatedAt,
-			&newComment.Post.User.ID, &newComment.Post.User.UserName, &newComment.Post.User.FullName, &newComment.Post.User.Email, &newComment.Post.User.ProfilePicture,
+			&newComment.Post.User.ID, &newComment.Post.User.UserName, &newComment.Post.User.FullName, &newComment.Post.User.ProfilePicture,
 			&newComment.Post.User.Bio, &newComment.Post.User.IsActive, &newComment.Post.User.Role,
 			&newComment.Post.Topic.ID, &newComment.Post.Topic.Name, &newComment.Post.Topic.Description, &newComment.Post.Topic.CreatedAt,
 		); err != nil {
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+)
+
+func (s *PostgresStore) GetDigestSettings(userID int) (*models.DigestSettings, error) {
+	stmt := "SELECT frequency, last_sent_at FROM email_digests WHERE user_id = $1;"
+
+	settings := new(models.DigestSettings)
+	err := s.Db.QueryRow(stmt, userID).Scan(&settings.Frequency, &settings.LastSentAt)
+	if err == sql.ErrNoRows {
+		// Digests are opt-in
+		return &models.DigestSettings{Frequency: models.DigestOff}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func (s *PostgresStore) UpdateDigestSettings(userID int, frequency string) (*models.DigestSettings, error) {
+	stmt := `
+	INSERT INTO email_digests (user_id, frequency)
+	VALUES ($1, $2)
+	ON CONFLICT (user_id) DO UPDATE 
+	SET frequency = EXCLUDED.frequency, updated_at = now()
+	RETURNING frequency, last_sent_at;
+	`
+
+	settings := new(models.DigestSettings)
+	if err := s.Db.QueryRow(stmt, userID, frequency).Scan(&settings.Frequency, &settings.LastSentAt); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// GetDueDigestRecipients returns the active users whose last digest is older than their frequency
+func (s *PostgresStore) GetDueDigestRecipients(now time.Time) ([]models.DigestRecipient, error) {
+	stmt := `
+	SELECT u.id, u.user_name, u.full_name, u.email, ed.frequency,
+		COALESCE(ed.last_sent_at, $1::timestamptz - CASE WHEN ed.frequency = 'daily' THEN interval '1 day' ELSE interval '7 days' END)
+	FROM email_digests ed
+	JOIN users u ON u.id = ed.user_id
+	WHERE u.is_active AND (
+		(ed.frequency = 'daily' AND (ed.last_sent_at IS NULL OR ed.last_sent_at <= $1::timestamptz - interval '1 day'))
+		OR (ed.frequency = 'weekly' AND (ed.last_sent_at IS NULL OR ed.last_sent_at <= $1::timestamptz - interval '7 days'))
+	);
+	`
+
+	rows, err := s.Db.Query(stmt, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipients []models.DigestRecipient
+	for rows.Next() {
+		var recipient models.DigestRecipient
+		err := rows.Scan(&recipient.User.ID, &recipient.User.UserName, &recipient.User.FullName, &recipient.User.Email,
+			&recipient.Frequency, &recipient.Since)
+		if err != nil {
+			return nil, err
+		}
+
+		recipients = append(recipients, recipient)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return recipients, nil
+}
+
+// MarkDigestSent closes the digest period at sentAt, a period that ended later is never moved back
+func (s *PostgresStore) MarkDigestSent(userID int, sentAt time.Time) error {
+	stmt := "UPDATE email_digests SET last_sent_at = $2 WHERE user_id = $1 AND (last_sent_at IS NULL OR last_sent_at < $2);"
+
+	_, err := s.Db.Exec(stmt, userID, sentAt)
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"strconv"
 
+	"github.com/Marc-Garcia-Coronado/socialNetwork/domain"
 	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
 )
 
@@ -15,24 +16,38 @@ func (s *PostgresStore) CreateEvent(event *models.EventReq) (*models.EventWithUs
     RETURNING id, name, description, location, created_at, creator_id, topic_id, date, picture
 	)
 	SELECT e.id, e.name, e.description, e.location, e.created_at, e.date, e.picture,
-		   u.id AS creator_id, u.user_name, u.full_name, u.email, u.profile_picture, u.is_active, u.role,
+		   u.id AS creator_id, u.user_name, u.full_name, u.profile_picture, u.is_active, u.role,
 		   t.id AS topic_id, t.name AS topic_name, t.description AS topic_description, t.created_at AS topic_created_at
 	FROM inserted_event e
 	JOIN users u ON u.id = e.creator_id
 	JOIN topics t ON t.id = e.topic_id;
 	`
 
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	newEvent := new(models.EventWithUser)
-	row := s.Db.QueryRow(stmt, event.Name, event.Description, event.CreatorID, event.Location, event.Date, event.TopicID, event.Picture)
-	err := row.Scan(&newEvent.ID, &newEvent.Name, &newEvent.Description, &newEvent.Location, &newEvent.CreatedAt, &newEvent.Date, &newEvent.Picture,
+	row := tx.QueryRow(stmt, event.Name, event.Description, event.CreatorID, event.Location, event.Date, event.TopicID, event.Picture)
+	err = row.Scan(&newEvent.ID, &newEvent.Name, &newEvent.Description, &newEvent.Location, &newEvent.CreatedAt, &newEvent.Date, &newEvent.Picture,
 		&newEvent.Creator.ID, &newEvent.Creator.UserName, &newEvent.Creator.FullName,
-		&newEvent.Creator.Email, &newEvent.Creator.ProfilePicture, &newEvent.Creator.IsActive, &newEvent.Creator.Role,
+		&newEvent.Creator.ProfilePicture, &newEvent.Creator.IsActive, &newEvent.Creator.Role,
 		&newEvent.Topic.ID, &newEvent.Topic.Name, &newEvent.Topic.Description, &newEvent.Topic.CreatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := insertOutboxEvent(tx, domain.EventCreated{Base: domain.NewBase(), Event: *newEvent}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	return newEvent, nil
 }
 
@@ -45,7 +60,7 @@ func (s *PostgresStore) GetAllEvents(limit, offset int, query string, topicID st
 
 	stmt := `
 	SELECT e.id, e.name, e.description, e.location, e.created_at, e.date, e.picture,
-		   u.id AS creator_id, u.user_name, u.full_name, u.email, u.profile_picture, u.is_active, u.role,
+		   u.id AS creator_id, u.user_name, u.full_name, u.profile_picture, u.is_active, u.role,
 		   t.id AS topic_id, t.name AS topic_name, t.description AS topic_description, t.created_at AS topic_created_at
 	FROM events e
 	JOIN users u ON u.id = e.creator_id
@@ -66,7 +81,7 @@ func (s *PostgresStore) GetAllEvents(limit, offset int, query string, topicID st
 		newEvent := new(models.EventWithUser)
 		err := rows.Scan(&newEvent.ID, &newEvent.Name, &newEvent.Description, &newEvent.Location, &newEvent.CreatedAt, &newEvent.Date, &newEvent.Picture,
 			&newEvent.Creator.ID, &newEvent.Creator.UserName, &newEvent.Creator.FullName,
-			&newEvent.Creator.Email, &newEvent.Creator.ProfilePicture, &newEvent.Creator.IsActive, &newEvent.Creator.Role,
+			&newEvent.Creator.ProfilePicture, &newEvent.Creator.IsActive, &newEvent.Creator.Role,
 			&newEvent.Topic.ID, &newEvent.Topic.Name, &newEvent.Topic.Description, &newEvent.Topic.CreatedAt,
 		)
 		if err != nil {
@@ -87,7 +102,7 @@ func (s *PostgresStore) GetClosestEvents() ([]models.EventWithUser, error) {
 
 	stmt := `
 	SELECT e.id, e.name, e.description, e.location, e.created_at, e.date, e.picture,
-		   u.id AS creator_id, u.user_name, u.full_name, u.email, u.profile_picture, u.is_active, u.role,
+		   u.id AS creator_id, u.user_name, u.full_name, u.profile_picture, u.is_active, u.role,
 		   t.id AS topic_id, t.name AS topic_name, t.description AS topic_description, t.created_at AS topic_created_at
 	FROM events e
 	JOIN users u ON u.id = e.creator_id
@@ -107,7 +122,7 @@ func (s *PostgresStore) GetClosestEvents() ([]models.EventWithUser, error) {
 		newEvent := new(models.EventWithUser)
 		err := rows.Scan(&newEvent.ID, &newEvent.Name, &newEvent.Description, &newEvent.Location, &newEvent.CreatedAt, &newEvent.Date, &newEvent.Picture,
 			&newEvent.Creator.ID, &newEvent.Creator.UserName, &newEvent.Creator.FullName,
-			&newEvent.Creator.Email, &newEvent.Creator.ProfilePicture, &newEvent.Creator.IsActive, &newEvent.Creator.Role,
+			&newEvent.Creator.ProfilePicture, &newEvent.Creator.IsActive, &newEvent.Creator.Role,
 			&newEvent.Topic.ID, &newEvent.Topic.Name, &newEvent.Topic.Description, &newEvent.Topic.CreatedAt,
 		)
 		if err != nil {
@@ -143,7 +158,7 @@ func (s *PostgresStore) GetAllEventsByTopic(topicID, limit, offset int) ([]model
 
 	stmt := `
 	SELECT e.id, e.name, e.description, e.location, e.created_at, e.date, e.picture,
-		   u.id AS creator_id, u.user_name, u.full_name, u.email, u.profile_picture, u.is_active, u.role,
+		   u.id AS creator_id, u.user_name, u.full_name, u.profile_picture, u.is_active, u.role,
 		   t.id AS topic_id, t.name AS topic_name, t.description AS topic_description, t.created_at AS topic_created_at
 	FROM events e
 	JOIN users u ON u.id = e.creator_id
@@ -164,7 +179,7 @@ func (s *PostgresStore) GetAllEventsByTopic(topicID, limit, offset int) ([]model
 		newEvent := new(models.EventWithUser)
 		err := rows.Scan(&newEvent.ID, &newEvent.Name, &newEvent.Description, &newEvent.Location, &newEvent.CreatedAt, &newEvent.Date, &newEvent.Picture,
 			&newEvent.Creator.ID, &newEvent.Creator.UserName, &newEvent.Creator.FullName,
-			&newEvent.Creator.Email, &newEvent.Creator.ProfilePicture, &newEvent.Creator.IsActive, &newEvent.Creator.Role,
+			&newEvent.Creator.ProfilePicture, &newEvent.Creator.IsActive, &newEvent.Creator.Role,
 			&newEvent.Topic.ID, &newEvent.Topic.Name, &newEvent.Topic.Description, &newEvent.Topic.CreatedAt,
 		)
 		if err != nil {
@@ -210,7 +225,7 @@ func (s *PostgresStore) GetUserEvents(userID, limit, offset int) ([]models.Event
 
 	stmt := `
 	SELECT e.id, e.name, e.description, e.location, e.created_at, e.date, e.picture,
-		   u.id AS creator_id, u.user_name, u.full_name, u.email, u.profile_picture, u.is_active, u.role,
+		   u.id AS creator_id, u.user_name, u.full_name, u.profile_picture, u.is_active, u.role,
 		   t.id AS topic_id, t.name AS topic_name, t.description AS topic_description, t.created_at AS topic_created_at
 	FROM events e
 	JOIN users u ON u.id = e.creator_id
@@ -231,7 +246,7 @@ func (s *PostgresStore) GetUserEvents(userID, limit, offset int) ([]models.Event
 		newEvent := new(models.EventWithUser)
 		err := rows.Scan(&newEvent.ID, &newEvent.Name, &newEvent.Description, &newEvent.Location, &newEvent.CreatedAt, &newEvent.Date, &newEvent.Picture,
 			&newEvent.Creator.ID, &newEvent.Creator.UserName, &newEvent.Creator.FullName,
-			&newEvent.Creator.Email, &newEvent.Creator.ProfilePicture, &newEvent.Creator.IsActive, &newEvent.Creator.Role,
+			&newEvent.Creator.ProfilePicture, &newEvent.Creator.IsActive, &newEvent.Creator.Role,
 			&newEvent.Topic.ID, &newEvent.Topic.Name, &newEvent.Topic.Description, &newEvent.Topic.CreatedAt,
 		)
 		if err != nil {
@@ -268,7 +283,7 @@ func (s *PostgresStore) UpdateEvent(event map[string]any, eventID int) (*models.
 
 	stmt += ` RETURNING 
 		events.id, events.name, events.description, events.location, events.created_at, events.date, events.picture,
-		u.id, u.user_name, u.full_name, u.email, u.profile_picture, u.is_active, u.role,
+		u.id, u.user_name, u.full_name, u.profile_picture, u.is_active, u.role,
 		t.id, t.name, t.description, t.created_at;`
 
 	values = append(values, eventID)
@@ -279,7 +294,7 @@ func (s *PostgresStore) UpdateEvent(event map[string]any, eventID int) (*models.
 		&updatedEvent.ID, &updatedEvent.Name, &updatedEvent.Description,
 		&updatedEvent.Location, &updatedEvent.CreatedAt, &updatedEvent.Date, &updatedEvent.Picture,
 		&updatedEvent.Creator.ID, &updatedEvent.Creator.UserName,
-		&updatedEvent.Creator.FullName, &updatedEvent.Creator.Email,
+		&updatedEvent.Creator.FullName,
 		&updatedEvent.Creator.ProfilePicture, &updatedEvent.Creator.IsActive,
 		&updatedEvent.Creator.Role,
 		&updatedEvent.Topic.ID, &updatedEvent.Topic.Name,
@@ -319,11 +334,21 @@ func (s *PostgresStore) SubscribeEvent(eventID, userID int) error {
 	VALUES ($1, $2);
 	`
 
-	if err := s.Db.QueryRow(stmt, userID, eventID).Err(); err != nil {
+	tx, err := s.Db.Begin()
+	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	return nil
+	if _, err := tx.Exec(stmt, userID, eventID); err != nil {
+		return err
+	}
+
+	if err := insertOutboxEvent(tx, domain.EventSubscribed{Base: domain.NewBase(), EventID: eventID, UserID: userID}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (s *PostgresStore) UnsubscribeEvent(eventID, userID int) error {
@@ -344,7 +369,7 @@ func (s *PostgresStore) GetUserSubscribedEvents(userID, limit, offset int) ([]mo
 	}
 
 	stmt := `
-	SELECT e.id, e.name, e.description, e.picture, u.id, u.user_name, u.full_name, u.email, u.profile_picture, u.is_active, u.role, e.location, 
+	SELECT e.id, e.name, e.description, e.picture, u.id, u.user_name, u.full_name, u.profile_picture, u.is_active, u.role, e.location, 
 		t.id, t.name, t.description, t.created_at, e.created_at, e.date, ue.subscribed_at
 	FROM user_event ue
 	JOIN events e ON e.id = ue.event_id
@@ -367,7 +392,7 @@ func (s *PostgresStore) GetUserSubscribedEvents(userID, limit, offset int) ([]mo
 		newEvent := new(models.SubscribedEvent)
 		err := rows.Scan(&newEvent.ID, &newEvent.Name, &newEvent.Description, &newEvent.Picture,
 			&newEvent.Creator.ID, &newEvent.Creator.UserName, &newEvent.Creator.FullName,
-			&newEvent.Creator.Email, &newEvent.Creator.ProfilePicture, &newEvent.Creator.IsActive, &newEvent.Creator.Role, &newEvent.Location,
+			&newEvent.Creator.ProfilePicture, &newEvent.Creator.IsActive, &newEvent.Creator.Role, &newEvent.Location,
 			&newEvent.Topic.ID, &newEvent.Topic.Name, &newEvent.Topic.Description, &newEvent.Topic.CreatedAt,
 			&newEvent.CreatedAt, &newEvent.Date, &newEvent.SubscribedAt,
 		)
@@ -394,3 +419,55 @@ func (s *PostgresStore) GetUserSubscribedEventsCount(userID int) (*int, error) {
 
 	return totalCount, nil
 }
+
+func (s *PostgresStore) GetEventCreatorID(eventID int) (int, error) {
+	var userID int
+	if err := s.Db.QueryRow("SELECT creator_id FROM events WHERE id = $1;", eventID).Scan(&userID); err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+// GetUpcomingSubscribedEvents returns the next events the user is subscribed to
+func (s *PostgresStore) GetUpcomingSubscribedEvents(userID int, limit int) ([]models.SubscribedEvent, error) {
+	stmt := `
+	SELECT e.id, e.name, e.description, e.picture, u.id, u.user_name, u.full_name, u.profile_picture, u.is_active, u.role, e.location, 
+		t.id, t.name, t.description, t.created_at, e.created_at, e.date, ue.subscribed_at
+	FROM user_event ue
+	JOIN events e ON e.id = ue.event_id
+	JOIN users u ON u.id = e.creator_id 
+	JOIN topics t ON t.id = e.topic_id
+	WHERE ue.user_id = $1 AND e.date > now()
+	ORDER BY e.date ASC
+	LIMIT $2;
+	`
+
+	rows, err := s.Db.Query(stmt, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var arrayEvents []models.SubscribedEvent
+	for rows.Next() {
+		newEvent := new(models.SubscribedEvent)
+		err := rows.Scan(&newEvent.ID, &newEvent.Name, &newEvent.Description, &newEvent.Picture,
+			&newEvent.Creator.ID, &newEvent.Creator.UserName, &newEvent.Creator.FullName,
+			&newEvent.Creator.ProfilePicture, &newEvent.Creator.IsActive, &newEvent.Creator.Role, &newEvent.Location,
+			&newEvent.Topic.ID, &newEvent.Topic.Name, &newEvent.Topic.Description, &newEvent.Topic.CreatedAt,
+			&newEvent.CreatedAt, &newEvent.Date, &newEvent.SubscribedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		arrayEvents = append(arrayEvents, *newEvent)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return arrayEvents, nil
+}
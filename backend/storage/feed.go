@@ -1,58 +1,50 @@
 package storage
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
 )
 
-func (s *PostgresStore) GetUserFeed(userID, limit, offset int) ([]models.Post, int, error) {
-	queryCount := `
-	SELECT COUNT(DISTINCT p.id) 
-	FROM posts p
-	JOIN topics_user tu ON tu.topic_id = p.topic_id
-	WHERE tu.user_id = $1`
+// feedFilter keeps the posts the viewer ($1) sees in the feed: of the topics they follow, not of blocked or
+// muted users and not of private accounts they do not follow. Every feed query uses it so they stay in sync
+const feedFilter = `
+	EXISTS (SELECT 1 FROM topics_user tu WHERE tu.topic_id = p.topic_id AND tu.user_id = $1)
+	AND NOT EXISTS (
+		SELECT 1 FROM user_blocks b
+		WHERE (b.blocker_id = $1 AND b.blocked_id = p.user_id) OR (b.blocker_id = p.user_id AND b.blocked_id = $1)
+	)
+	AND NOT EXISTS (SELECT 1 FROM user_mutes m WHERE m.user_id = $1 AND m.muted_user_id = p.user_id)
+	AND (NOT u.is_private OR u.id = $1 OR EXISTS (SELECT 1 FROM user_follow_user WHERE user_following_id = $1 AND user_followed_id = u.id))`
 
-	var totalCount int
-	if err := s.Db.QueryRow(queryCount, userID).Scan(&totalCount); err != nil {
-		return nil, 0, err
-	}
+const feedCountStmt = `
+	SELECT COUNT(*)
+	FROM posts p
+	JOIN users u ON u.id = p.user_id
+	WHERE ` + feedFilter + ` AND %s;`
 
-	query := `
-	SELECT DISTINCT 
+// feedPostsStmt lists the feed posts matching the condition, it is completed with the condition, the order and the limit
+const feedPostsStmt = `
+	SELECT
 		p.id, p.picture, p.title, p.created_at,
-		u.id AS user_id, u.user_name, u.full_name, u.email, u.profile_picture, u.is_active, u.role,
+		u.id AS user_id, u.user_name, u.full_name, u.profile_picture, u.is_active, u.role,
 		t.id AS topic_id, t.name, t.description, t.created_at AS topic_created_at
 	FROM posts p
-	JOIN topics_user tu ON tu.topic_id = p.topic_id
 	JOIN users u ON u.id = p.user_id
 	JOIN topics t ON t.id = p.topic_id
-	WHERE tu.user_id = $1
-	ORDER BY p.created_at DESC
-	LIMIT $2 OFFSET $3;
-	`
+	WHERE ` + feedFilter + ` AND %s
+	ORDER BY %s
+	LIMIT %s;`
 
-	rows, err := s.Db.Query(query, userID, limit, offset)
-	if err != nil {
+func (s *PostgresStore) GetUserFeed(userID, limit, offset int) ([]models.Post, int, error) {
+	var totalCount int
+	if err := s.Db.QueryRow(fmt.Sprintf(feedCountStmt, "true"), userID).Scan(&totalCount); err != nil {
 		return nil, 0, err
 	}
-	defer rows.Close()
-
-	var posts []models.Post
-	for rows.Next() {
-		var post models.Post
-		err := rows.Scan(
-			&post.ID, &post.Picture, &post.Title, &post.CreatedAt,
-			&post.User.ID, &post.User.UserName, &post.User.FullName,
-			&post.User.Email, &post.User.ProfilePicture, &post.User.IsActive, &post.User.Role,
-			&post.Topic.ID, &post.Topic.Name, &post.Topic.Description,
-			&post.Topic.CreatedAt,
-		)
-		if err != nil {
-			return nil, 0, err
-		}
-		posts = append(posts, post)
-	}
 
-	if err = rows.Err(); err != nil {
+	posts, err := s.getFeedPosts(fmt.Sprintf(feedPostsStmt, "true", "p.created_at DESC", "$2 OFFSET $3"), userID, limit, offset)
+	if err != nil {
 		return nil, 0, err
 	}
 
@@ -60,35 +52,34 @@ func (s *PostgresStore) GetUserFeed(userID, limit, offset int) ([]models.Post, i
 }
 
 func (s *PostgresStore) GetUserFeedByTopic(userID, topicID, limit, offset int) ([]models.Post, int, error) {
-	queryCount := `
-	SELECT COUNT(DISTINCT p.id) 
-	FROM posts p
-	JOIN topics_user tu ON tu.topic_id = p.topic_id
-	WHERE tu.user_id = $1 AND p.topic_id = $2`
-
 	var totalCount int
-	if err := s.Db.QueryRow(queryCount, userID, topicID).Scan(&totalCount); err != nil {
+	if err := s.Db.QueryRow(fmt.Sprintf(feedCountStmt, "p.topic_id = $2"), userID, topicID).Scan(&totalCount); err != nil {
 		return nil, 0, err
 	}
 
-	query := `
-	SELECT DISTINCT 
-		p.id, p.picture, p.title, p.created_at,
-		u.id AS user_id, u.user_name, u.full_name, u.email, u.profile_picture, u.is_active, u.role,
-		t.id AS topic_id, t.name, t.description, t.created_at AS topic_created_at
-	FROM posts p
-	JOIN topics_user tu ON tu.topic_id = p.topic_id
-	JOIN users u ON u.id = p.user_id
-	JOIN topics t ON t.id = p.topic_id
-	WHERE tu.user_id = $1 AND p.topic_id = $2
-	ORDER BY p.created_at DESC
-	LIMIT $3 OFFSET $4;
-	`
-
-	rows, err := s.Db.Query(query, userID, topicID, limit, offset)
+	posts, err := s.getFeedPosts(fmt.Sprintf(feedPostsStmt, "p.topic_id = $2", "p.created_at DESC", "$3 OFFSET $4"), userID, topicID, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
+
+	return posts, totalCount, nil
+}
+
+// GetTopFeedPosts returns the most liked posts of the user feed created after the given time
+func (s *PostgresStore) GetTopFeedPosts(userID int, since time.Time, limit int) ([]models.Post, error) {
+	stmt := fmt.Sprintf(feedPostsStmt,
+		"p.created_at > $2 AND p.user_id <> $1",
+		"(SELECT COUNT(*) FROM likes l WHERE l.post_id = p.id) DESC, p.created_at DESC",
+		"$3")
+
+	return s.getFeedPosts(stmt, userID, since, limit)
+}
+
+func (s *PostgresStore) getFeedPosts(stmt string, args ...any) ([]models.Post, error) {
+	rows, err := s.Db.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	var posts []models.Post
@@ -97,19 +88,19 @@ func (s *PostgresStore) GetUserFeedByTopic(userID, topicID, limit, offset int) (
 		err := rows.Scan(
 			&post.ID, &post.Picture, &post.Title, &post.CreatedAt,
 			&post.User.ID, &post.User.UserName, &post.User.FullName,
-			&post.User.Email, &post.User.ProfilePicture, &post.User.IsActive, &post.User.Role,
+			&post.User.ProfilePicture, &post.User.IsActive, &post.User.Role,
 			&post.Topic.ID, &post.Topic.Name, &post.Topic.Description,
 			&post.Topic.CreatedAt,
 		)
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 		posts = append(posts, post)
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, 0, err
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return posts, totalCount, nil
+	return posts, nil
 }
@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/domain"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+)
+
+func (s *PostgresStore) GetAccountPrivacy(userID int) (bool, error) {
+	var isPrivate bool
+	if err := s.Db.QueryRow("SELECT is_private FROM users WHERE id = $1;", userID).Scan(&isPrivate); err != nil {
+		if err == sql.ErrNoRows {
+			return false, fmt.Errorf("user not found")
+		}
+		return false, err
+	}
+
+	return isPrivate, nil
+}
+
+// SetAccountPrivacy changes the privacy of the account, making it public approves all the pending follow requests
+func (s *PostgresStore) SetAccountPrivacy(userID int, isPrivate bool) error {
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE users SET is_private = $2 WHERE id = $1;", userID, isPrivate); err != nil {
+		return err
+	}
+
+	if isPrivate {
+		return tx.Commit()
+	}
+
+	stmt := `
+	WITH approved AS (
+		DELETE FROM follow_requests WHERE target_id = $1
+		RETURNING requester_id, created_at
+	)
+	INSERT INTO user_follow_user (user_following_id, user_followed_id)
+	SELECT requester_id, $1 FROM approved ORDER BY created_at
+	ON CONFLICT (user_following_id, user_followed_id) DO NOTHING
+	RETURNING user_following_id;
+	`
+
+	rows, err := tx.Query(stmt, userID)
+	if err != nil {
+		return err
+	}
+
+	var followerIDs []int
+	for rows.Next() {
+		var followerID int
+		if err := rows.Scan(&followerID); err != nil {
+			rows.Close()
+			return err
+		}
+		followerIDs = append(followerIDs, followerID)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, followerID := range followerIDs {
+		if err := insertOutboxEvent(tx, domain.UserFollowed{Base: domain.NewBase(), FollowerID: followerID, FollowedID: userID, Approved: true}); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CanViewProfile tells if the viewer can see the posts and follows of the user, private accounts
+// are only visible to themselves and their followers and blocked users never see each other
+func (s *PostgresStore) CanViewProfile(userID, viewerID int) (bool, error) {
+	stmt := `
+	SELECT u.id = $2 OR (
+		NOT EXISTS (
+			SELECT 1 FROM user_blocks b
+			WHERE (b.blocker_id = $1 AND b.blocked_id = $2) OR (b.blocker_id = $2 AND b.blocked_id = $1)
+		)
+		AND (NOT u.is_private OR EXISTS (
+			SELECT 1 FROM user_follow_user WHERE user_following_id = $2 AND user_followed_id = u.id
+		))
+	)
+	FROM users u
+	WHERE u.id = $1;
+	`
+
+	var canView bool
+	if err := s.Db.QueryRow(stmt, userID, viewerID).Scan(&canView); err != nil {
+		if err == sql.ErrNoRows {
+			return false, fmt.Errorf("user not found")
+		}
+		return false, err
+	}
+
+	return canView, nil
+}
+
+// GetFollowRequests returns the pending requests received by the user, with the requester of each one
+func (s *PostgresStore) GetFollowRequests(userID, limit, offset int) ([]models.FollowRequest, int, error) {
+	stmt := `
+	SELECT fr.id, fr.created_at, u.id, u.user_name, u.full_name, u.profile_picture, u.role
+	FROM follow_requests fr
+	JOIN users u ON u.id = fr.requester_id
+	WHERE fr.target_id = $1
+	ORDER BY fr.created_at DESC
+	LIMIT $2 OFFSET $3;
+	`
+
+	return s.getFollowRequests("SELECT COUNT(*) FROM follow_requests WHERE target_id = $1;", stmt, userID, limit, offset)
+}
+
+// GetSentFollowRequests returns the pending requests sent by the user, with the target of each one
+func (s *PostgresStore) GetSentFollowRequests(userID, limit, offset int) ([]models.FollowRequest, int, error) {
+	stmt := `
+	SELECT fr.id, fr.created_at, u.id, u.user_name, u.full_name, u.profile_picture, u.role
+	FROM follow_requests fr
+	JOIN users u ON u.id = fr.target_id
+	WHERE fr.requester_id = $1
+	ORDER BY fr.created_at DESC
+	LIMIT $2 OFFSET $3;
+	`
+
+	return s.getFollowRequests("SELECT COUNT(*) FROM follow_requests WHERE requester_id = $1;", stmt, userID, limit, offset)
+}
+
+func (s *PostgresStore) getFollowRequests(queryCount, stmt string, userID, limit, offset int) ([]models.FollowRequest, int, error) {
+	var totalCount int
+	if err := s.Db.QueryRow(queryCount, userID).Scan(&totalCount); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.Db.Query(stmt, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var requests []models.FollowRequest
+	for rows.Next() {
+		var request models.FollowRequest
+		if err := rows.Scan(&request.ID, &request.CreatedAt, &request.User.ID, &request.User.UserName, &request.User.FullName,
+			&request.User.ProfilePicture, &request.User.Role); err != nil {
+			return nil, 0, err
+		}
+		requests = append(requests, request)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return requests, totalCount, nil
+}
+
+// ApproveFollowRequest turns the pending request of requesterID into a follow of userID
+func (s *PostgresStore) ApproveFollowRequest(requesterID, userID int) error {
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM follow_requests WHERE requester_id = $1 AND target_id = $2;", requesterID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("no follow request found")
+	}
+
+	stmt := `
+	INSERT INTO user_follow_user (user_following_id, user_followed_id)
+	VALUES ($1, $2)
+	ON CONFLICT (user_following_id, user_followed_id) DO NOTHING;
+	`
+
+	res, err = tx.Exec(stmt, requesterID, userID)
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if rowsAffected > 0 {
+		if err := insertOutboxEvent(tx, domain.UserFollowed{Base: domain.NewBase(), FollowerID: requesterID, FollowedID: userID, Approved: true}); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteFollowRequest removes a pending request, it is used both to reject and to cancel it
+func (s *PostgresStore) DeleteFollowRequest(requesterID, targetID int) error {
+	res, err := s.Db.Exec("DELETE FROM follow_requests WHERE requester_id = $1 AND target_id = $2;", requesterID, targetID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("no follow request found")
+	}
+
+	return nil
+}
@@ -1,22 +1,95 @@
 package storage
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/domain"
 	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
 )
 
-func (s *PostgresStore) FollowUser(userToFollowID, userID int) error {
+// FollowUser follows the user, private accounts get a follow request instead. It returns the resulting follow status
+func (s *PostgresStore) FollowUser(userToFollowID, userID int) (string, error) {
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	// The row is locked so the account cannot become public while the request is created
+	stmtState := `
+	SELECT u.is_private, EXISTS (
+		SELECT 1 FROM user_follow_user WHERE user_following_id = $2 AND user_followed_id = u.id
+	), EXISTS (
+		SELECT 1 FROM user_blocks WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+	)
+	FROM users u
+	WHERE u.id = $1
+	FOR SHARE;
+	`
+
+	var isPrivate, following, blocked bool
+	if err := tx.QueryRow(stmtState, userToFollowID, userID).Scan(&isPrivate, &following, &blocked); err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("user not found")
+		}
+		return "", err
+	}
+
+	if blocked {
+		return "", ErrBlocked
+	}
+
+	if isPrivate && !following && userToFollowID != userID {
+		if err := requestFollow(tx, userToFollowID, userID); err != nil {
+			return "", err
+		}
+		return models.FollowStatusRequested, tx.Commit()
+	}
+
 	stmt := `
 	INSERT INTO user_follow_user (user_following_id, user_followed_id)
 	VALUES ($1, $2) 
 	ON CONFLICT (user_following_id, user_followed_id) DO NOTHING;
 	`
 
-	if err := s.Db.QueryRow(stmt, userID, userToFollowID).Err(); err != nil {
+	res, err := tx.Exec(stmt, userID, userToFollowID)
+	if err != nil {
+		return "", err
+	}
+
+	// Following again someone already followed is not a new event
+	if rowsAffected, err := res.RowsAffected(); err != nil || rowsAffected == 0 {
+		return models.FollowStatusFollowing, err
+	}
+
+	if err := insertOutboxEvent(tx, domain.UserFollowed{Base: domain.NewBase(), FollowerID: userID, FollowedID: userToFollowID}); err != nil {
+		return "", err
+	}
+
+	return models.FollowStatusFollowing, tx.Commit()
+}
+
+func requestFollow(tx *sql.Tx, targetID, requesterID int) error {
+	stmt := `
+	INSERT INTO follow_requests (requester_id, target_id)
+	VALUES ($1, $2)
+	ON CONFLICT (requester_id, target_id) DO NOTHING;
+	`
+
+	res, err := tx.Exec(stmt, requesterID, targetID)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	// Requesting again is not a new event
+	if rowsAffected, err := res.RowsAffected(); err != nil || rowsAffected == 0 {
+		return err
+	}
+
+	return insertOutboxEvent(tx, domain.FollowRequested{Base: domain.NewBase(), RequesterID: requesterID, TargetID: targetID})
 }
 
 func (s *PostgresStore) UnfollowUser(userToFollowID, userID int) error {
@@ -38,7 +111,7 @@ func (s *PostgresStore) UnfollowUser(userToFollowID, userID int) error {
 	return nil
 }
 
-func (s *PostgresStore) GetFollowers(id, limit, offset int) ([]models.User, int, error) {
+func (s *PostgresStore) GetFollowers(id, limit, offset int) ([]models.PublicUser, int, error) {
 	var totalCount int
 	queryCount := "SELECT COUNT(*) FROM user_follow_user WHERE user_followed_id = $1;"
 	if err := s.Db.QueryRow(queryCount, id).Scan(&totalCount); err != nil {
@@ -46,7 +119,7 @@ func (s *PostgresStore) GetFollowers(id, limit, offset int) ([]models.User, int,
 	}
 
 	stmt := `
-	SELECT u.id, u.user_name, u.full_name, u.email, u.profile_picture, u.role
+	SELECT u.id, u.user_name, u.full_name, u.profile_picture, u.role
 	FROM users u
 	JOIN user_follow_user ufu ON u.id = ufu.user_following_id
 	WHERE ufu.user_followed_id = $1
@@ -59,10 +132,10 @@ func (s *PostgresStore) GetFollowers(id, limit, offset int) ([]models.User, int,
 	}
 	defer rows.Close()
 
-	var users []models.User
+	var users []models.PublicUser
 	for rows.Next() {
-		user := new(models.User)
-		if err := rows.Scan(&user.ID, &user.UserName, &user.FullName, &user.Email, &user.ProfilePicture, &user.Role); err != nil {
+		user := new(models.PublicUser)
+		if err := rows.Scan(&user.ID, &user.UserName, &user.FullName, &user.ProfilePicture, &user.Role); err != nil {
 			return nil, 0, err
 		}
 		users = append(users, *user)
@@ -74,7 +147,7 @@ func (s *PostgresStore) GetFollowers(id, limit, offset int) ([]models.User, int,
 	return users, totalCount, nil
 }
 
-func (s *PostgresStore) GetFollows(id, limit, offset int) ([]models.User, int, error) {
+func (s *PostgresStore) GetFollows(id, limit, offset int) ([]models.PublicUser, int, error) {
 	var totalCount int
 	queryCount := "SELECT COUNT(*) FROM user_follow_user WHERE user_following_id = $1;"
 	if err := s.Db.QueryRow(queryCount, id).Scan(&totalCount); err != nil {
@@ -82,7 +155,7 @@ func (s *PostgresStore) GetFollows(id, limit, offset int) ([]models.User, int, e
 	}
 
 	stmt := `
-	SELECT u.id, u.user_name, u.full_name, u.email, u.profile_picture, u.role
+	SELECT u.id, u.user_name, u.full_name, u.profile_picture, u.role
 	FROM users u
 	JOIN user_follow_user ufu ON u.id = ufu.user_following_id
 	WHERE ufu.user_following_id = $1
@@ -96,10 +169,10 @@ func (s *PostgresStore) GetFollows(id, limit, offset int) ([]models.User, int, e
 	}
 	defer rows.Close()
 
-	var users []models.User
+	var users []models.PublicUser
 	for rows.Next() {
-		user := new(models.User)
-		if err := rows.Scan(&user.ID, &user.UserName, &user.FullName, &user.Email, &user.ProfilePicture, &user.Role); err != nil {
+		user := new(models.PublicUser)
+		if err := rows.Scan(&user.ID, &user.UserName, &user.FullName, &user.ProfilePicture, &user.Role); err != nil {
 			return nil, 0, err
 		}
 		users = append(users, *user)
@@ -150,4 +223,36 @@ func (s *PostgresStore) CheckIfFollowing(followerID, followedID int) (bool, erro
     }
 
     return isFollowing, nil
-}
\ No newline at end of file
+}
+// GetNewFollowers returns the users that started following the user after the given time
+func (s *PostgresStore) GetNewFollowers(userID int, since time.Time, limit int) ([]models.PublicUser, error) {
+	stmt := `
+	SELECT u.id, u.user_name, u.full_name, u.profile_picture, u.role
+	FROM users u
+	JOIN user_follow_user ufu ON u.id = ufu.user_following_id
+	WHERE ufu.user_followed_id = $1 AND ufu.followed_at > $2
+	ORDER BY ufu.followed_at DESC
+	LIMIT $3;
+	`
+
+	rows, err := s.Db.Query(stmt, userID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.PublicUser
+	for rows.Next() {
+		var user models.PublicUser
+		if err := rows.Scan(&user.ID, &user.UserName, &user.FullName, &user.ProfilePicture, &user.Role); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
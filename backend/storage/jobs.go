@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/lib/pq"
+)
+
+// ErrJobClaimLost is returned when the job is not running the claimed attempt anymore
+var ErrJobClaimLost = errors.New("the job is not running the claimed attempt anymore")
+
+func (s *PostgresStore) EnqueueJob(jobType string, payload []byte, runAt time.Time, maxAttempts int) (int64, error) {
+	stmt := `
+	INSERT INTO jobs (type, payload, run_at, max_attempts)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id;
+	`
+
+	var id int64
+	if err := s.Db.QueryRow(stmt, jobType, payload, runAt, maxAttempts).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// ClaimJob takes the next due job of the given types, nil means there is nothing to do.
+// SKIP LOCKED lets every worker of every instance claim a different job
+func (s *PostgresStore) ClaimJob(types []string) (*models.Job, error) {
+	stmt := `
+	UPDATE jobs
+	SET status = 'running', attempts = attempts + 1, locked_at = now(), updated_at = now()
+	WHERE id = (
+		SELECT id FROM jobs
+		WHERE status = 'pending' AND run_at <= now() AND type = ANY($1)
+		ORDER BY run_at, id
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING id, type, payload, status, attempts, max_attempts, run_at, last_error, created_at, updated_at;
+	`
+
+	job, err := scanJob(s.Db.QueryRow(stmt, pq.Array(types)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// HeartbeatJob refreshes the lock of a running job so RescueStaleJobs leaves it alone
+func (s *PostgresStore) HeartbeatJob(id int64, attempts int) error {
+	return s.updateClaimedJob("UPDATE jobs SET locked_at = now() WHERE id = $1 AND attempts = $2 AND status = 'running';", id, attempts)
+}
+
+func (s *PostgresStore) CompleteJob(id int64, attempts int) error {
+	stmt := `
+	UPDATE jobs SET status = 'done', locked_at = null, last_error = null, updated_at = now()
+	WHERE id = $1 AND attempts = $2 AND status = 'running';
+	`
+
+	return s.updateClaimedJob(stmt, id, attempts)
+}
+
+// RetryJob puts the failed job back in the queue to run again at runAt
+func (s *PostgresStore) RetryJob(id int64, attempts int, jobErr string, runAt time.Time) error {
+	stmt := `
+	UPDATE jobs SET status = 'pending', locked_at = null, last_error = $3, run_at = $4, updated_at = now()
+	WHERE id = $1 AND attempts = $2 AND status = 'running';
+	`
+
+	return s.updateClaimedJob(stmt, id, attempts, jobErr, runAt)
+}
+
+// KillJob moves the job to the dead letter, it is not run again unless an admin requeues it
+func (s *PostgresStore) KillJob(id int64, attempts int, jobErr string) error {
+	stmt := `
+	UPDATE jobs SET status = 'dead', locked_at = null, last_error = $3, updated_at = now()
+	WHERE id = $1 AND attempts = $2 AND status = 'running';
+	`
+
+	return s.updateClaimedJob(stmt, id, attempts, jobErr)
+}
+
+// updateClaimedJob runs an update fenced on the attempt that claimed the job, so a worker whose job was
+// rescued and claimed again by another one cannot overwrite the new attempt
+func (s *PostgresStore) updateClaimedJob(stmt string, args ...any) error {
+	res, err := s.Db.Exec(stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrJobClaimLost
+	}
+
+	return nil
+}
+
+// RescueStaleJobs returns to the queue the jobs of workers that stopped while running them
+func (s *PostgresStore) RescueStaleJobs(lockedBefore time.Time) (int64, error) {
+	stmt := `
+	UPDATE jobs
+	SET status = CASE WHEN attempts >= max_attempts THEN 'dead'::job_status ELSE 'pending'::job_status END,
+		locked_at = null, last_error = 'the worker stopped while running the job', updated_at = now()
+	WHERE status = 'running' AND locked_at < $1;
+	`
+
+	res, err := s.Db.Exec(stmt, lockedBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (s *PostgresStore) GetJobs(status string, limit, offset int) ([]models.Job, int, error) {
+	var totalCount int
+	queryCount := "SELECT COUNT(*) FROM jobs WHERE status = $1;"
+	if err := s.Db.QueryRow(queryCount, status).Scan(&totalCount); err != nil {
+		return nil, 0, err
+	}
+
+	stmt := `
+	SELECT id, type, payload, status, attempts, max_attempts, run_at, last_error, created_at, updated_at
+	FROM jobs
+	WHERE status = $1
+	ORDER BY updated_at DESC
+	LIMIT $2 OFFSET $3;
+	`
+
+	rows, err := s.Db.Query(stmt, status, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var jobs []models.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		jobs = append(jobs, *job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return jobs, totalCount, nil
+}
+
+// RequeueDeadJob gives a dead job a new round of attempts
+func (s *PostgresStore) RequeueDeadJob(id int64) error {
+	stmt := "UPDATE jobs SET status = 'pending', attempts = 0, run_at = now(), updated_at = now() WHERE id = $1 AND status = 'dead';"
+
+	res, err := s.Db.Exec(stmt, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("no dead job found to requeue")
+	}
+
+	return nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanJob(row rowScanner) (*models.Job, error) {
+	job := new(models.Job)
+	var payload []byte
+	err := row.Scan(&job.ID, &job.Type, &payload, &job.Status, &job.Attempts, &job.MaxAttempts,
+		&job.RunAt, &job.LastError, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	job.Payload = payload
+
+	return job, nil
+}
+
+// PurgeFinishedJobs deletes the done jobs, the dead ones stay until an admin deals with them
+func (s *PostgresStore) PurgeFinishedJobs(before time.Time) (int64, error) {
+	res, err := s.Db.Exec("DELETE FROM jobs WHERE status = 'done' AND updated_at < $1;", before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
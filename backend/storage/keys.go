@@ -0,0 +1,302 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+)
+
+// Key bundles a user can fetch per hour, every fetch consumes one time pre keys of the other user
+const maxKeyBundleFetchesPerHour = 60
+
+// ErrTooManyKeyBundleFetches is returned when the requester fetched too many key bundles in the last hour
+var ErrTooManyKeyBundleFetches = errors.New("too many key bundle requests, try again later")
+
+// PublishKeys registers the device keys of the user, publishing again for the same device
+// replaces the identity and all its pre keys
+func (s *PostgresStore) PublishKeys(userID int, deviceID string, keys *models.PublishKeysReq) error {
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt := `
+	INSERT INTO user_devices (user_id, device_id, registration_id, identity_key, signed_pre_key_id, signed_pre_key, signed_pre_key_signature)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (user_id, device_id) DO UPDATE 
+	SET registration_id = EXCLUDED.registration_id, identity_key = EXCLUDED.identity_key,
+	    signed_pre_key_id = EXCLUDED.signed_pre_key_id, signed_pre_key = EXCLUDED.signed_pre_key,
+	    signed_pre_key_signature = EXCLUDED.signed_pre_key_signature, updated_at = now()
+	RETURNING id;
+	`
+
+	var id int
+	err = tx.QueryRow(stmt, userID, deviceID, keys.RegistrationID, keys.IdentityKey,
+		keys.SignedPreKey.KeyID, keys.SignedPreKey.PublicKey, keys.SignedPreKey.Signature).Scan(&id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM one_time_pre_keys WHERE device_id = $1;", id); err != nil {
+		return err
+	}
+
+	if err := insertPreKeys(tx, id, keys.OneTimePreKeys); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgresStore) UploadPreKeys(userID int, deviceID string, preKeys []models.OneTimePreKey) error {
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int
+	stmt := "SELECT id FROM user_devices WHERE user_id = $1 AND device_id = $2;"
+	if err := tx.QueryRow(stmt, userID, deviceID).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("device not found")
+		}
+		return err
+	}
+
+	if err := insertPreKeys(tx, id, preKeys); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertPreKeys(tx *sql.Tx, deviceID int, preKeys []models.OneTimePreKey) error {
+	stmt := `
+	INSERT INTO one_time_pre_keys (device_id, key_id, public_key)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (device_id, key_id) DO NOTHING;
+	`
+
+	for _, preKey := range preKeys {
+		if _, err := tx.Exec(stmt, deviceID, preKey.KeyID, preKey.PublicKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) GetUserDevices(userID int) ([]models.Device, error) {
+	stmt := `
+	SELECT d.device_id, d.registration_id, d.identity_key, d.created_at, d.updated_at,
+	       (SELECT COUNT(*) FROM one_time_pre_keys otpk WHERE otpk.device_id = d.id)
+	FROM user_devices d
+	WHERE d.user_id = $1
+	ORDER BY d.created_at;
+	`
+
+	rows, err := s.Db.Query(stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devices []models.Device
+	for rows.Next() {
+		device := new(models.Device)
+		if err := rows.Scan(&device.DeviceID, &device.RegistrationID, &device.IdentityKey,
+			&device.CreatedAt, &device.UpdatedAt, &device.OneTimePreKeysLen); err != nil {
+			return nil, err
+		}
+		devices = append(devices, *device)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+func (s *PostgresStore) DeleteDevice(userID int, deviceID string) error {
+	res, err := s.Db.Exec("DELETE FROM user_devices WHERE user_id = $1 AND device_id = $2;", userID, deviceID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("no device found to delete")
+	}
+
+	return nil
+}
+
+// GetKeyBundles returns a bundle for every device of the user and consumes one of the
+// one time pre keys of each device. Blocked users get nothing and the fetches of every
+// requester are limited so nobody drains the pre keys of another user
+func (s *PostgresStore) GetKeyBundles(requesterID, userID int) ([]models.KeyBundle, error) {
+	if requesterID != userID {
+		blocked, err := s.IsBlocked(requesterID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if blocked {
+			return nil, ErrBlocked
+		}
+	}
+
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The fetches older than the window are not needed anymore
+	if _, err := tx.Exec("DELETE FROM key_bundle_fetches WHERE requester_id = $1 AND fetched_at <= now() - interval '1 hour';", requesterID); err != nil {
+		return nil, err
+	}
+
+	var fetches int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM key_bundle_fetches WHERE requester_id = $1;", requesterID).Scan(&fetches); err != nil {
+		return nil, err
+	}
+	if fetches >= maxKeyBundleFetchesPerHour {
+		return nil, ErrTooManyKeyBundleFetches
+	}
+
+	if _, err := tx.Exec("INSERT INTO key_bundle_fetches (requester_id) VALUES ($1);", requesterID); err != nil {
+		return nil, err
+	}
+
+	stmt := `
+	SELECT d.id, d.device_id, d.registration_id, d.identity_key, d.signed_pre_key_id, d.signed_pre_key, d.signed_pre_key_signature
+	FROM user_devices d
+	WHERE d.user_id = $1
+	ORDER BY d.created_at;
+	`
+
+	rows, err := tx.Query(stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	var bundles []models.KeyBundle
+	for rows.Next() {
+		var id int
+		bundle := models.KeyBundle{UserID: userID}
+		if err := rows.Scan(&id, &bundle.DeviceID, &bundle.RegistrationID, &bundle.IdentityKey,
+			&bundle.SignedPreKey.KeyID, &bundle.SignedPreKey.PublicKey, &bundle.SignedPreKey.Signature); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+		bundles = append(bundles, bundle)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// SKIP LOCKED so two users fetching the same bundle at once never get the same pre key
+	stmtPreKey := `
+	DELETE FROM one_time_pre_keys
+	WHERE id = (
+		SELECT id FROM one_time_pre_keys 
+		WHERE device_id = $1 
+		ORDER BY key_id 
+		LIMIT 1 
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING key_id, public_key;
+	`
+
+	for i, id := range ids {
+		preKey := new(models.OneTimePreKey)
+		err := tx.QueryRow(stmtPreKey, id).Scan(&preKey.KeyID, &preKey.PublicKey)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		bundles[i].OneTimePreKey = preKey
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return bundles, nil
+}
+
+// saveCiphertexts stores the opaque payload of an encrypted message for every device,
+// the devices must belong to one of the participants of the message
+func saveCiphertexts(tx *sql.Tx, message *models.MessageReq, messageID int) error {
+	stmt := `
+	INSERT INTO message_ciphertexts (message_id, device_id, ciphertext)
+	SELECT $1, d.id, $4
+	FROM user_devices d
+	WHERE d.user_id = $2 AND d.device_id = $3 AND d.user_id IN ($5, $6);
+	`
+
+	for _, c := range message.Ciphertexts {
+		res, err := tx.Exec(stmt, messageID, c.UserID, c.DeviceID, c.Ciphertext, message.SenderID, message.ReceiverID)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("unknown device %s of user %d", c.DeviceID, c.UserID)
+		}
+	}
+
+	return nil
+}
+
+// GetConversationCiphertexts returns the payloads of the conversation for one device of the user by message id
+func (s *PostgresStore) GetConversationCiphertexts(userID, otherUserID int, deviceID string) (map[int]string, error) {
+	stmt := `
+	SELECT mc.message_id, mc.ciphertext
+	FROM message_ciphertexts mc
+	JOIN user_devices d ON d.id = mc.device_id
+	JOIN messages m ON m.id = mc.message_id
+	WHERE d.user_id = $1 AND d.device_id = $3
+	AND ((m.sender_id = $1 AND m.receiver_id = $2) OR (m.sender_id = $2 AND m.receiver_id = $1));
+	`
+
+	rows, err := s.Db.Query(stmt, userID, otherUserID, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ciphertexts := make(map[int]string)
+	for rows.Next() {
+		var messageID int
+		var ciphertext string
+		if err := rows.Scan(&messageID, &ciphertext); err != nil {
+			return nil, err
+		}
+		ciphertexts[messageID] = ciphertext
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ciphertexts, nil
+}
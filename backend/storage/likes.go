@@ -3,19 +3,51 @@ package storage
 import (
 	"errors"
 
+	"github.com/Marc-Garcia-Coronado/socialNetwork/domain"
 	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
 )
 
+// Queries that tell if the user $1 and the owner of the post or comment $2 blocked each other
+const (
+	stmtPostOwnerBlocked = `
+	SELECT EXISTS (
+		SELECT 1 FROM posts p
+		JOIN user_blocks b ON (b.blocker_id = $1 AND b.blocked_id = p.user_id) OR (b.blocker_id = p.user_id AND b.blocked_id = $1)
+		WHERE p.id = $2
+	);`
+	stmtCommentOwnerBlocked = `
+	SELECT EXISTS (
+		SELECT 1 FROM comments c
+		JOIN user_blocks b ON (b.blocker_id = $1 AND b.blocked_id = c.user_id) OR (b.blocker_id = c.user_id AND b.blocked_id = $1)
+		WHERE c.id = $2
+	);`
+)
+
 func (s *PostgresStore) LikePost(userID, postID int) error {
 	stmt := `
 	INSERT INTO likes (user_id, post_id)
 	VALUES ($1, $2);
 	`
 
-	if err := s.Db.QueryRow(stmt, userID, postID).Err(); err != nil {
+	tx, err := s.Db.Begin()
+	if err != nil {
 		return err
 	}
-	return nil
+	defer tx.Rollback()
+
+	if err := checkNotBlocked(tx, stmtPostOwnerBlocked, userID, postID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(stmt, userID, postID); err != nil {
+		return err
+	}
+
+	if err := insertOutboxEvent(tx, domain.PostLiked{Base: domain.NewBase(), UserID: userID, PostID: postID}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (s *PostgresStore) LikeComment(userID, commentID int) error {
@@ -24,6 +56,10 @@ func (s *PostgresStore) LikeComment(userID, commentID int) error {
 	VALUES ($1, $2);
 	`
 
+	if err := checkNotBlocked(s.Db, stmtCommentOwnerBlocked, userID, commentID); err != nil {
+		return err
+	}
+
 	if err := s.Db.QueryRow(stmt, userID, commentID).Err(); err != nil {
 		return err
 	}
@@ -75,9 +111,9 @@ func (s *PostgresStore) GetPostLikes(postID, limit, offset int) ([]models.LikePo
 
 	stmt := `
 	SELECT l.id, l.created_at, 
-		   u.id AS user_id, u.user_name, u.full_name, u.email, u.profile_picture, u.bio, u.is_active, u.role,
+		   u.id AS user_id, u.user_name, u.full_name, u.profile_picture, u.bio, u.is_active, u.role,
 		   p.id AS post_id, p.picture, p.title, p.created_at AS post_created_at,
-		   pu.id AS post_user_id, pu.user_name AS post_user_name, pu.full_name AS post_full_name, pu.email AS post_user_email, pu.profile_picture AS post_user_profile_picture,
+		   pu.id AS post_user_id, pu.user_name AS post_user_name, pu.full_name AS post_full_name, pu.profile_picture AS post_user_profile_picture,
 	       pu.bio AS post_user_bio, pu.is_active AS post_user_is_active, pu.role AS post_user_role,
 	       tu.id AS topic_id, tu.name AS topic_name, tu.description AS topic_description, tu.created_at AS topic_created_at
 	FROM likes l
@@ -101,10 +137,10 @@ func (s *PostgresStore) GetPostLikes(postID, limit, offset int) ([]models.LikePo
 		if err := rows.Scan(
 			&newLike.ID, &newLike.CreatedAt,
 			&newLike.User.ID, &newLike.User.UserName, &newLike.User.FullName,
-			&newLike.User.Email, &newLike.User.ProfilePicture, &newLike.User.Bio, &newLike.User.IsActive, &newLike.User.Role,
+			&newLike.User.ProfilePicture, &newLike.User.Bio, &newLike.User.IsActive, &newLike.User.Role,
 			&newLike.Post.ID, &newLike.Post.Picture, &newLike.Post.Title, &newLike.Post.CreatedAt,
 			&newLike.Post.User.ID, &newLike.Post.User.UserName, &newLike.Post.User.FullName,
-			&newLike.Post.User.Email, &newLike.Post.User.ProfilePicture, &newLike.Post.User.Bio,
+			&newLike.Post.User.ProfilePicture, &newLike.Post.User.Bio,
 			&newLike.Post.User.IsActive, &newLike.Post.User.Role,
 			&newLike.Post.Topic.ID, &newLike.Post.Topic.Name, &newLike.Post.Topic.Description, &newLike.Post.Topic.CreatedAt,
 		); err != nil {
@@ -125,10 +161,10 @@ func (s *PostgresStore) GetCommentLikes(commentID, limit, offset int) ([]models.
 
 	stmt := `
 	SELECT l.id, l.created_at, 
-		   u.id AS user_id, u.user_name, u.full_name, u.email, u.profile_picture, u.bio, u.is_active, u.role,
+		   u.id AS user_id, u.user_name, u.full_name, u.profile_picture, u.bio, u.is_active, u.role,
 		   c.id AS comment_id, c.body, c.created_at AS comment_created_at,
 		   p.id AS post_id, p.picture, p.title, p.created_at AS post_created_at,
-		   pu.id AS post_user_id, pu.user_name AS post_user_name, pu.full_name AS post_full_name, pu.email AS post_user_email, pu.profile_picture AS post_user_profile_picture,
+		   pu.id AS post_user_id, pu.user_name AS post_user_name, pu.full_name AS post_full_name, pu.profile_picture AS post_user_profile_picture,
 	       pu.bio AS post_user_bio, pu.is_active AS post_user_is_active, pu.role AS post_user_role,
 	       tu.id AS topic_id, tu.name AS topic_name, tu.description AS topic_description, tu.created_at AS topic_created_at
 	FROM likes l
@@ -153,11 +189,11 @@ func (s *PostgresStore) GetCommentLikes(commentID, limit, offset int) ([]models.
 		if err := rows.Scan(
 			&newLike.ID, &newLike.CreatedAt,
 			&newLike.User.ID, &newLike.User.UserName, &newLike.User.FullName,
-			&newLike.User.Email, &newLike.User.ProfilePicture, &newLike.User.Bio, &newLike.User.IsActive, &newLike.User.Role,
+			&newLike.User.ProfilePicture, &newLike.User.Bio, &newLike.User.IsActive, &newLike.User.Role,
 			&newLike.Comment.ID, &newLike.Comment.Body, &newLike.Comment.CreatedAt,
 			&newLike.Comment.Post.ID, &newLike.Comment.Post.Picture, &newLike.Comment.Post.Title, &newLike.Comment.Post.CreatedAt,
 			&newLike.Comment.Post.User.ID, &newLike.Comment.Post.User.UserName, &newLike.Comment.Post.User.FullName,
-			&newLike.Comment.Post.User.Email, &newLike.Comment.Post.User.ProfilePicture, &newLike.Comment.Post.User.Bio,
+			&newLike.Comment.Post.User.ProfilePicture, &newLike.Comment.Post.User.Bio,
 			&newLike.Comment.Post.User.IsActive, &newLike.Comment.Post.User.Role,
 			&newLike.Comment.Post.Topic.ID, &newLike.Comment.Post.Topic.Name, &newLike.Comment.Post.Topic.Description, &newLike.Comment.Post.Topic.CreatedAt,
 		); err != nil {
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/lib/pq"
+)
+
+// stmtMessagePartnerBlocked checks a block between the user ($1) and the other participant of the message ($2)
+const stmtMessagePartnerBlocked = `
+	SELECT EXISTS (
+		SELECT 1 FROM messages m
+		JOIN user_blocks b ON (b.blocker_id = $1 AND b.blocked_id IN (m.sender_id, m.receiver_id))
+			OR (b.blocked_id = $1 AND b.blocker_id IN (m.sender_id, m.receiver_id))
+		WHERE m.id = $2
+	);`
+
+// AddMessageReaction adds the reaction only if the user is one of the message participants and no one blocked the other
+func (s *PostgresStore) AddMessageReaction(messageID, userID int, emoji string) error {
+	stmt := `
+	INSERT INTO message_reactions (message_id, user_id, emoji)
+	SELECT m.id, $2, $3
+	FROM messages m
+	WHERE m.id = $1 AND (m.sender_id = $2 OR m.receiver_id = $2)
+	ON CONFLICT (message_id, user_id, emoji) DO NOTHING
+	RETURNING id;
+	`
+
+	if err := checkNotBlocked(s.Db, stmtMessagePartnerBlocked, userID, messageID); err != nil {
+		return err
+	}
+
+	var id int
+	if err := s.Db.QueryRow(stmt, messageID, userID, emoji).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("message not found or reaction already added")
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) RemoveMessageReaction(messageID, userID int, emoji string) error {
+	stmt := `DELETE FROM message_reactions WHERE message_id = $1 AND user_id = $2 AND emoji = $3;`
+
+	res, err := s.Db.Exec(stmt, messageID, userID, emoji)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("no reaction found to delete")
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) GetMessageParticipants(messageID int) (int, int, error) {
+	var senderID, receiverID int
+	stmt := "SELECT sender_id, receiver_id FROM messages WHERE id = $1;"
+	if err := s.Db.QueryRow(stmt, messageID).Scan(&senderID, &receiverID); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, 0, fmt.Errorf("message not found")
+		}
+		return 0, 0, err
+	}
+
+	return senderID, receiverID, nil
+}
+
+// getConversationReactions returns the aggregated reactions of the conversation grouped by message id
+func (s *PostgresStore) getConversationReactions(from, to int) (map[int][]models.MessageReaction, error) {
+	stmt := `
+	SELECT mr.message_id, mr.emoji, COUNT(*), array_agg(mr.user_id ORDER BY mr.created_at)
+	FROM message_reactions mr
+	JOIN messages m ON m.id = mr.message_id
+	WHERE (m.sender_id = $1 AND m.receiver_id = $2) OR (m.sender_id = $2 AND m.receiver_id = $1)
+	GROUP BY mr.message_id, mr.emoji
+	ORDER BY MIN(mr.created_at);
+	`
+
+	rows, err := s.Db.Query(stmt, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reactions := make(map[int][]models.MessageReaction)
+	for rows.Next() {
+		var messageID int
+		var userIDs []int64
+		reaction := new(models.MessageReaction)
+		if err := rows.Scan(&messageID, &reaction.Emoji, &reaction.Count, pq.Array(&userIDs)); err != nil {
+			return nil, err
+		}
+
+		for _, id := range userIDs {
+			reaction.UserIDs = append(reaction.UserIDs, int(id))
+		}
+		reactions[messageID] = append(reactions[messageID], *reaction)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reactions, nil
+}
@@ -0,0 +1,233 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+)
+
+// ErrMessageRequestPending is returned when the conversation is still a request the receiver did not accept
+var ErrMessageRequestPending = errors.New("the message request is not accepted yet")
+
+// GetMessageDelivery decides what happens with a message from senderID to receiverID.
+// Established conversations (the receiver follows the sender, accepted the request or
+// already wrote to the sender) are delivered directly, the rest depends on the
+// receiver message privacy.
+func (s *PostgresStore) GetMessageDelivery(senderID, receiverID int) (string, error) {
+	stmt := `
+	SELECT 
+		EXISTS (SELECT 1 FROM message_requests WHERE sender_id = $1 AND receiver_id = $2 AND status = 'blocked')
+		OR EXISTS (SELECT 1 FROM user_blocks WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)),
+		EXISTS (SELECT 1 FROM message_requests WHERE sender_id = $1 AND receiver_id = $2 AND status = 'accepted'),
+		EXISTS (SELECT 1 FROM user_follow_user WHERE user_following_id = $2 AND user_followed_id = $1),
+		EXISTS (SELECT 1 FROM user_follow_user WHERE user_following_id = $1 AND user_followed_id = $2),
+		EXISTS (SELECT 1 FROM messages WHERE sender_id = $2 AND receiver_id = $1 AND NOT is_system),
+		u.message_privacy
+	FROM users u
+	WHERE u.id = $2;
+	`
+
+	var blocked, accepted, receiverFollows, senderFollows, replied bool
+	var privacy string
+	err := s.Db.QueryRow(stmt, senderID, receiverID).Scan(&blocked, &accepted, &receiverFollows, &senderFollows, &replied, &privacy)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("user not found")
+		}
+		return "", err
+	}
+
+	if blocked {
+		return models.MessageDeliveryDenied, nil
+	}
+
+	if accepted || receiverFollows || replied {
+		return models.MessageDeliveryDirect, nil
+	}
+
+	switch privacy {
+	case models.MessagePrivacyEveryone:
+		return models.MessageDeliveryRequest, nil
+	case models.MessagePrivacyFollowers:
+		if senderFollows {
+			return models.MessageDeliveryRequest, nil
+		}
+	}
+
+	return models.MessageDeliveryDenied, nil
+}
+
+func (s *PostgresStore) CreateMessageRequest(senderID, receiverID int) error {
+	stmt := `
+	INSERT INTO message_requests (sender_id, receiver_id)
+	VALUES ($1, $2)
+	ON CONFLICT (sender_id, receiver_id) DO UPDATE SET updated_at = now()
+	WHERE message_requests.status = 'pending';
+	`
+
+	if _, err := s.Db.Exec(stmt, senderID, receiverID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) GetMessageRequests(userID int) ([]models.MessageRequest, error) {
+	stmt := `
+	SELECT mr.id, mr.status, mr.created_at, mr.updated_at,
+	       u.id, u.user_name, u.full_name, u.profile_picture, u.is_active, u.role,
+	       COALESCE(lm.content, ''), COALESCE(lm.messages_count, 0)
+	FROM message_requests mr
+	JOIN users u ON u.id = mr.sender_id
+	LEFT JOIN LATERAL (
+		SELECT 
+			(SELECT content FROM messages WHERE sender_id = mr.sender_id AND receiver_id = mr.receiver_id AND created_at > hb.hidden_before ORDER BY created_at DESC LIMIT 1) AS content,
+			(SELECT COUNT(*) FROM messages WHERE sender_id = mr.sender_id AND receiver_id = mr.receiver_id AND created_at > hb.hidden_before) AS messages_count
+		FROM (
+			-- The messages of a declined request are not shown again when the sender writes a new one
+			SELECT COALESCE((
+				SELECT cs.hidden_before FROM conversation_settings cs
+				WHERE cs.user_id = mr.receiver_id AND cs.other_user_id = mr.sender_id
+			), '-infinity') AS hidden_before
+		) hb
+	) lm ON true
+	WHERE mr.receiver_id = $1 AND mr.status = 'pending'
+	ORDER BY mr.updated_at DESC;
+	`
+
+	rows, err := s.Db.Query(stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []models.MessageRequest
+	for rows.Next() {
+		request := new(models.MessageRequest)
+		if err := rows.Scan(&request.ID, &request.Status, &request.CreatedAt, &request.UpdatedAt,
+			&request.Sender.ID, &request.Sender.UserName, &request.Sender.FullName,
+			&request.Sender.ProfilePicture, &request.Sender.IsActive, &request.Sender.Role,
+			&request.LastMessage, &request.MessagesCount,
+		); err != nil {
+			return nil, err
+		}
+		requests = append(requests, *request)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+func (s *PostgresStore) AcceptMessageRequest(senderID, receiverID int) error {
+	stmt := `
+	UPDATE message_requests 
+	SET status = 'accepted', updated_at = now()
+	WHERE sender_id = $1 AND receiver_id = $2 AND status = 'pending';
+	`
+
+	res, err := s.Db.Exec(stmt, senderID, receiverID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("no message request found to accept")
+	}
+
+	return nil
+}
+
+// DeclineMessageRequest removes the request and hides its messages from the receiver, the sender keeps them
+// and can write again later
+func (s *PostgresStore) DeclineMessageRequest(senderID, receiverID int) error {
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM message_requests WHERE sender_id = $1 AND receiver_id = $2 AND status = 'pending';", senderID, receiverID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("no message request found to decline")
+	}
+
+	if err := hideConversation(tx, receiverID, senderID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// BlockMessageRequest hides the request messages from the receiver and denies any further message from the sender
+func (s *PostgresStore) BlockMessageRequest(senderID, receiverID int) error {
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt := `
+	INSERT INTO message_requests (sender_id, receiver_id, status)
+	VALUES ($1, $2, 'blocked')
+	ON CONFLICT (sender_id, receiver_id) DO UPDATE SET status = 'blocked', updated_at = now();
+	`
+	if _, err := tx.Exec(stmt, senderID, receiverID); err != nil {
+		return err
+	}
+
+	if err := hideConversation(tx, receiverID, senderID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// hideConversation hides from the user the messages of the conversation sent until now, the other user still sees them
+func hideConversation(tx *sql.Tx, userID, otherUserID int) error {
+	stmt := `
+	INSERT INTO conversation_settings (user_id, other_user_id, hidden_before)
+	VALUES ($1, $2, now())
+	ON CONFLICT (user_id, other_user_id) DO UPDATE 
+	SET hidden_before = EXCLUDED.hidden_before;
+	`
+
+	_, err := tx.Exec(stmt, userID, otherUserID)
+	return err
+}
+
+func (s *PostgresStore) GetMessagePrivacy(userID int) (string, error) {
+	var privacy string
+	if err := s.Db.QueryRow("SELECT message_privacy FROM users WHERE id = $1;", userID).Scan(&privacy); err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("user not found")
+		}
+		return "", err
+	}
+
+	return privacy, nil
+}
+
+func (s *PostgresStore) UpdateMessagePrivacy(userID int, privacy string) error {
+	if _, err := s.Db.Exec("UPDATE users SET message_privacy = $1 WHERE id = $2;", privacy, userID); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+)
+
+func (s *PostgresStore) GetConversationRetention(userID, otherUserID int) (*models.ConversationRetention, error) {
+	stmt := `
+	SELECT retention_seconds, updated_by, updated_at
+	FROM conversation_retention
+	WHERE user_low_id = LEAST($1::int, $2::int) AND user_high_id = GREATEST($1::int, $2::int);
+	`
+
+	retention := new(models.ConversationRetention)
+	err := s.Db.QueryRow(stmt, userID, otherUserID).Scan(&retention.RetentionSeconds, &retention.UpdatedBy, &retention.UpdatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return retention, nil
+}
+
+// SetConversationRetention changes the disappearing messages timer of the conversation and records the change
+// as a system message from the user, a retention of 0 turns it off
+func (s *PostgresStore) SetConversationRetention(userID, otherUserID, retentionSeconds int, label string) (*models.Message, error) {
+	// Only the users that can write to each other change the timer, the system message skips the checks of SaveMessage
+	blocked, err := s.IsBlocked(userID, otherUserID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, ErrBlocked
+	}
+
+	delivery, err := s.GetMessageDelivery(userID, otherUserID)
+	if err != nil {
+		return nil, err
+	}
+	switch delivery {
+	case models.MessageDeliveryDenied:
+		return nil, ErrBlocked
+	case models.MessageDeliveryRequest:
+		return nil, ErrMessageRequestPending
+	}
+
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	stmtExists := `
+	SELECT EXISTS (
+		SELECT 1 FROM messages 
+		WHERE (sender_id = $1 AND receiver_id = $2) OR (sender_id = $2 AND receiver_id = $1)
+	);
+	`
+	if err := tx.QueryRow(stmtExists, userID, otherUserID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("conversation not found")
+	}
+
+	if retentionSeconds == 0 {
+		stmt := `
+		DELETE FROM conversation_retention
+		WHERE user_low_id = LEAST($1::int, $2::int) AND user_high_id = GREATEST($1::int, $2::int);
+		`
+		if _, err := tx.Exec(stmt, userID, otherUserID); err != nil {
+			return nil, err
+		}
+	} else {
+		stmt := `
+		INSERT INTO conversation_retention (user_low_id, user_high_id, retention_seconds, updated_by)
+		VALUES (LEAST($1::int, $2::int), GREATEST($1::int, $2::int), $3, $1)
+		ON CONFLICT (user_low_id, user_high_id) DO UPDATE 
+		SET retention_seconds = EXCLUDED.retention_seconds, updated_by = EXCLUDED.updated_by, updated_at = now();
+		`
+		if _, err := tx.Exec(stmt, userID, otherUserID, retentionSeconds); err != nil {
+			return nil, err
+		}
+	}
+
+	content := "disappearing messages turned off"
+	if retentionSeconds != 0 {
+		content = "disappearing messages set to " + label
+	}
+
+	systemMsg, err := saveMessage(tx, &models.MessageReq{
+		SenderID:   userID,
+		ReceiverID: otherUserID,
+		Content:    content,
+		IsSystem:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return systemMsg, nil
+}
+
+// PurgeExpiredMessages deletes the messages whose disappearing timer is over and returns how many were deleted
+func (s *PostgresStore) PurgeExpiredMessages(now time.Time) (int64, error) {
+	res, err := s.Db.Exec("DELETE FROM messages WHERE expires_at IS NOT NULL AND expires_at <= $1;", now)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
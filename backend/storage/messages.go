@@ -1,32 +1,106 @@
 package storage
 
 import (
+	"database/sql"
+	"time"
+
 	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
 )
 
+// queryRower is satisfied by both *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 func (s *PostgresStore) SaveMessage(message *models.MessageReq) (*models.Message, error) {
+	// System messages describe changes of the conversation, the users never write to each other when blocked
+	if !message.IsSystem {
+		blocked, err := s.IsBlocked(message.SenderID, message.ReceiverID)
+		if err != nil {
+			return nil, err
+		}
+		if blocked {
+			return nil, ErrBlocked
+		}
+	}
+
+	if message.Mode != models.MessageModeE2E {
+		return saveMessage(s.Db, message)
+	}
+
+	// Encrypted messages are stored with their ciphertexts or not stored at all
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	newMsg, err := saveMessage(tx, message)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := saveCiphertexts(tx, message, newMsg.ID); err != nil {
+		return nil, err
+	}
+	newMsg.Ciphertexts = message.Ciphertexts
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return newMsg, nil
+}
+
+func saveMessage(q queryRower, message *models.MessageReq) (*models.Message, error) {
 	stmt := `
 	WITH inserted_msg AS (
-		INSERT INTO messages (sender_id, receiver_id, content) 
-		VALUES ($1, $2, $3) 
-		RETURNING id, sender_id, receiver_id, content, created_at, is_read
+		INSERT INTO messages (sender_id, receiver_id, content, is_system, mode, expires_at) 
+		VALUES ($1, $2, $3, $4, $5, (
+			-- System messages are never purged
+			SELECT now() + make_interval(secs => cr.retention_seconds)
+			FROM conversation_retention cr
+			WHERE cr.user_low_id = LEAST($1::int, $2::int) AND cr.user_high_id = GREATEST($1::int, $2::int) AND NOT $4
+		)) 
+		RETURNING id, sender_id, receiver_id, content, created_at, is_read, is_system, mode, expires_at
+	), accepted_request AS (
+		-- Answering a pending request accepts it, and so does a message delivered directly because the
+		-- receiver followed the sender after the request. System messages are neither
+		UPDATE message_requests
+		SET status = 'accepted', updated_at = now()
+		WHERE status = 'pending' AND NOT $4 AND (
+			(sender_id = $2 AND receiver_id = $1)
+			OR (sender_id = $1 AND receiver_id = $2 AND EXISTS (
+				SELECT 1 FROM user_follow_user WHERE user_following_id = $2 AND user_followed_id = $1
+			))
+		)
+	), unarchived AS (
+		-- A new message brings the conversation back from the archive
+		UPDATE conversation_settings
+		SET archived = false
+		WHERE archived AND ((user_id = $1 AND other_user_id = $2) OR (user_id = $2 AND other_user_id = $1))
 	)
 
-	SELECT im.id, im.content, im.created_at, im.is_read,
-	       us.id AS sender_id, us.user_name, us.full_name, us.email, us.profile_picture, us.is_active, us.role,
-	       ur.id AS receiver_id, ur.user_name AS receiver_user_name, ur.full_name AS receiver_full_name, ur.email AS receiver_email, ur.profile_picture AS receiver_profile_picture, ur.is_active AS receiver_is_active, ur.role AS receiver_role
+	SELECT im.id, im.content, im.created_at, im.is_read, im.is_system, im.mode, im.expires_at,
+	       us.id AS sender_id, us.user_name, us.full_name, us.profile_picture, us.is_active, us.role,
+	       ur.id AS receiver_id, ur.user_name AS receiver_user_name, ur.full_name AS receiver_full_name, ur.profile_picture AS receiver_profile_picture, ur.is_active AS receiver_is_active, ur.role AS receiver_role
 	FROM inserted_msg im
 	JOIN users us ON us.id = im.sender_id
 	JOIN users ur ON ur.id = im.receiver_id;
 	`
 
+	mode := message.Mode
+	if mode == "" {
+		mode = models.MessageModePlain
+	}
+
 	newMsg := new(models.Message)
-	err := s.Db.QueryRow(stmt, message.SenderID, message.ReceiverID, message.Content).Scan(
-		&newMsg.ID, &newMsg.Content, &newMsg.CreatedAt, &newMsg.IsRead,
+	err := q.QueryRow(stmt, message.SenderID, message.ReceiverID, message.Content, message.IsSystem, mode).Scan(
+		&newMsg.ID, &newMsg.Content, &newMsg.CreatedAt, &newMsg.IsRead, &newMsg.IsSystem, &newMsg.Mode, &newMsg.ExpiresAt,
 		&newMsg.Sender.ID, &newMsg.Sender.UserName, &newMsg.Sender.FullName,
-		&newMsg.Sender.Email, &newMsg.Sender.ProfilePicture, &newMsg.Sender.IsActive, &newMsg.Sender.Role,
+		&newMsg.Sender.ProfilePicture, &newMsg.Sender.IsActive, &newMsg.Sender.Role,
 		&newMsg.Receiver.ID, &newMsg.Receiver.UserName, &newMsg.Receiver.FullName,
-		&newMsg.Receiver.Email, &newMsg.Receiver.ProfilePicture, &newMsg.Receiver.IsActive, &newMsg.Receiver.Role,
+		&newMsg.Receiver.ProfilePicture, &newMsg.Receiver.IsActive, &newMsg.Receiver.Role,
 	)
 	if err != nil {
 		return nil, err
@@ -38,13 +112,18 @@ func (s *PostgresStore) SaveMessage(message *models.MessageReq) (*models.Message
 func (s *PostgresStore) GetConversationMessages(from, to int) ([]models.Message, error) {
 
 	stmt := `
-	SELECT im.id, im.content, im.created_at, im.is_read,
-	       us.id AS sender_id, us.user_name, us.full_name, us.email, us.profile_picture, us.is_active, us.role,
-	       ur.id AS receiver_id, ur.user_name AS receiver_user_name, ur.full_name AS receiver_full_name, ur.email AS receiver_email, ur.profile_picture AS receiver_profile_picture, ur.is_active AS receiver_is_active, ur.role AS receiver_role
+	SELECT im.id, im.content, im.created_at, im.is_read, im.is_system, im.mode, im.expires_at,
+	       us.id AS sender_id, us.user_name, us.full_name, us.profile_picture, us.is_active, us.role,
+	       ur.id AS receiver_id, ur.user_name AS receiver_user_name, ur.full_name AS receiver_full_name, ur.profile_picture AS receiver_profile_picture, ur.is_active AS receiver_is_active, ur.role AS receiver_role
 	FROM messages im
 	JOIN users us ON us.id = im.sender_id
 	JOIN users ur ON ur.id = im.receiver_id
-	WHERE (sender_id = $1 AND receiver_id = $2) OR (sender_id = $2 AND receiver_id = $1)
+	WHERE ((sender_id = $1 AND receiver_id = $2) OR (sender_id = $2 AND receiver_id = $1))
+	AND (im.expires_at IS NULL OR im.expires_at > now())
+	AND NOT EXISTS (
+		SELECT 1 FROM conversation_settings cs
+		WHERE cs.user_id = $1 AND cs.other_user_id = $2 AND im.created_at <= cs.hidden_before
+	)
 	ORDER BY im.created_at DESC;
 	`
 
@@ -52,15 +131,16 @@ func (s *PostgresStore) GetConversationMessages(from, to int) ([]models.Message,
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	var arrayMessages []models.Message
 	for rows.Next() {
 		newMessage := new(models.Message)
-		err := rows.Scan(&newMessage.ID, &newMessage.Content, &newMessage.CreatedAt, &newMessage.IsRead,
+		err := rows.Scan(&newMessage.ID, &newMessage.Content, &newMessage.CreatedAt, &newMessage.IsRead, &newMessage.IsSystem, &newMessage.Mode, &newMessage.ExpiresAt,
 			&newMessage.Sender.ID, &newMessage.Sender.UserName, &newMessage.Sender.FullName,
-			&newMessage.Sender.Email, &newMessage.Sender.ProfilePicture, &newMessage.Sender.IsActive, &newMessage.Sender.Role,
+			&newMessage.Sender.ProfilePicture, &newMessage.Sender.IsActive, &newMessage.Sender.Role,
 			&newMessage.Receiver.ID, &newMessage.Receiver.UserName, &newMessage.Receiver.FullName,
-			&newMessage.Receiver.Email, &newMessage.Receiver.ProfilePicture, &newMessage.Receiver.IsActive, &newMessage.Receiver.Role,
+			&newMessage.Receiver.ProfilePicture, &newMessage.Receiver.IsActive, &newMessage.Receiver.Role,
 		)
 		if err != nil {
 			return nil, err
@@ -73,18 +153,38 @@ func (s *PostgresStore) GetConversationMessages(from, to int) ([]models.Message,
 		return nil, err
 	}
 
+	reactions, err := s.getConversationReactions(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range arrayMessages {
+		arrayMessages[i].Reactions = reactions[arrayMessages[i].ID]
+	}
+
 	return arrayMessages, nil
 }
 
-func (s *PostgresStore) GetUserConversations(userID int) ([]models.User, error) {
+func (s *PostgresStore) GetUserConversations(userID int, filter string) ([]models.Conversation, error) {
 	stmt := `
 	WITH last_messages AS (
     SELECT DISTINCT ON (
         LEAST(sender_id, receiver_id), 
         GREATEST(sender_id, receiver_id)
     ) *
-    FROM messages
-    WHERE sender_id = $1 OR receiver_id = $1
+    FROM messages m
+    WHERE (m.sender_id = $1 OR m.receiver_id = $1)
+    AND NOT EXISTS (
+        SELECT 1 FROM message_requests mr
+        WHERE mr.receiver_id = $1 AND mr.status <> 'accepted'
+        AND mr.sender_id = CASE WHEN m.sender_id = $1 THEN m.receiver_id ELSE m.sender_id END
+        AND NOT EXISTS (SELECT 1 FROM user_follow_user WHERE user_following_id = $1 AND user_followed_id = mr.sender_id)
+    )
+    AND NOT EXISTS (
+        SELECT 1 FROM conversation_settings cs
+        WHERE cs.user_id = $1 AND m.created_at <= cs.hidden_before
+        AND cs.other_user_id = CASE WHEN m.sender_id = $1 THEN m.receiver_id ELSE m.sender_id END
+    )
     ORDER BY 
         LEAST(sender_id, receiver_id), 
         GREATEST(sender_id, receiver_id), 
@@ -94,40 +194,90 @@ func (s *PostgresStore) GetUserConversations(userID int) ([]models.User, error)
 		us.id AS sender_id,
 		us.user_name,
 		us.full_name,
-		us.email,
 		us.profile_picture,
 		us.is_active,
-		us.role
+		us.role,
+		COALESCE(cs.pinned, false),
+		COALESCE(cs.archived, false),
+		COALESCE(cs.muted_until > now(), false),
+		cs.muted_until,
+		lm.created_at
 	FROM last_messages lm
 	JOIN users us ON (
 		(us.id = lm.receiver_id AND lm.sender_id = $1)
 		OR 
 		(us.id = lm.sender_id AND lm.receiver_id = $1)
 	)
-	ORDER BY lm.created_at DESC;
+	LEFT JOIN conversation_settings cs ON cs.user_id = $1 AND cs.other_user_id = us.id
+	WHERE $2 = 'all'
+		OR ($2 = 'inbox' AND NOT COALESCE(cs.archived, false))
+		OR ($2 = 'archived' AND COALESCE(cs.archived, false))
+		OR ($2 = 'pinned' AND COALESCE(cs.pinned, false))
+		OR ($2 = 'muted' AND COALESCE(cs.muted_until > now(), false))
+	ORDER BY COALESCE(cs.pinned, false) DESC, cs.pinned_at DESC NULLS LAST, lm.created_at DESC;
 	`
 
-	rows, err := s.Db.Query(stmt, userID)
+	rows, err := s.Db.Query(stmt, userID, filter)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	var arrayUsers []models.User
+	var conversations []models.Conversation
 	for rows.Next() {
-		newUser := new(models.User)
-		err := rows.Scan(&newUser.ID, &newUser.UserName, &newUser.FullName, &newUser.Email, &newUser.ProfilePicture, &newUser.IsActive, &newUser.Role)
+		conversation := new(models.Conversation)
+		err := rows.Scan(&conversation.ID, &conversation.UserName, &conversation.FullName,
+			&conversation.ProfilePicture, &conversation.IsActive, &conversation.Role,
+			&conversation.Pinned, &conversation.Archived, &conversation.Muted, &conversation.MutedUntil, &conversation.LastMessageAt)
 		if err != nil {
 			return nil, err
 		}
 
-		arrayUsers = append(arrayUsers, *newUser)
+		conversations = append(conversations, *conversation)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return arrayUsers, nil
+	return conversations, nil
+}
+
+func (s *PostgresStore) PinConversation(userID, otherUserID int, pinned bool) error {
+	stmt := `
+	INSERT INTO conversation_settings (user_id, other_user_id, pinned, pinned_at)
+	VALUES ($1, $2, $3, CASE WHEN $3 THEN now() END)
+	ON CONFLICT (user_id, other_user_id) DO UPDATE 
+	SET pinned = EXCLUDED.pinned, pinned_at = EXCLUDED.pinned_at;
+	`
+
+	_, err := s.Db.Exec(stmt, userID, otherUserID, pinned)
+	return err
+}
+
+func (s *PostgresStore) ArchiveConversation(userID, otherUserID int, archived bool) error {
+	stmt := `
+	INSERT INTO conversation_settings (user_id, other_user_id, archived)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (user_id, other_user_id) DO UPDATE 
+	SET archived = EXCLUDED.archived;
+	`
+
+	_, err := s.Db.Exec(stmt, userID, otherUserID, archived)
+	return err
+}
+
+// MuteConversation mutes the conversation until the given time, a nil time unmutes it
+func (s *PostgresStore) MuteConversation(userID, otherUserID int, until *time.Time) error {
+	stmt := `
+	INSERT INTO conversation_settings (user_id, other_user_id, muted_until)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (user_id, other_user_id) DO UPDATE 
+	SET muted_until = EXCLUDED.muted_until;
+	`
+
+	_, err := s.Db.Exec(stmt, userID, otherUserID, until)
+	return err
 }
 
 func (s *PostgresStore) ReadConversationMessages(from, to int) error {
@@ -144,8 +294,12 @@ func (s *PostgresStore) ReadConversationMessages(from, to int) error {
 func (s *PostgresStore) GetNotReadedConversationMessages(from, to int) (int, error) {
 	stmt := `
 	SELECT COUNT(*)
-	FROM messages
-	WHERE (sender_id = $2 AND receiver_id = $1) AND is_read = false;
+	FROM messages m
+	WHERE (m.sender_id = $2 AND m.receiver_id = $1) AND m.is_read = false
+	AND NOT EXISTS (
+		SELECT 1 FROM conversation_settings cs
+		WHERE cs.user_id = $1 AND cs.other_user_id = $2 AND m.created_at <= cs.hidden_before
+	);
 	`
 	var number int
 	err := s.Db.QueryRow(stmt, from, to).Scan(&number)
@@ -155,3 +309,45 @@ func (s *PostgresStore) GetNotReadedConversationMessages(from, to int) (int, err
 
 	return number, nil
 }
+
+// GetUnreadMessagesCount counts the unread messages received by the user in all the conversations
+func (s *PostgresStore) GetUnreadMessagesCount(userID int) (*int, error) {
+	stmt := `
+	SELECT COUNT(*)
+	FROM messages m
+	WHERE m.receiver_id = $1 AND NOT m.is_read
+	AND (m.expires_at IS NULL OR m.expires_at > now())
+	AND NOT EXISTS (
+		SELECT 1 FROM message_requests mr
+		WHERE mr.sender_id = m.sender_id AND mr.receiver_id = $1 AND mr.status <> 'accepted'
+		AND NOT EXISTS (SELECT 1 FROM user_follow_user WHERE user_following_id = $1 AND user_followed_id = m.sender_id)
+	)
+	AND NOT EXISTS (
+		SELECT 1 FROM conversation_settings cs
+		WHERE cs.user_id = $1 AND cs.other_user_id = m.sender_id AND m.created_at <= cs.hidden_before
+	);
+	`
+
+	var count *int
+	if err := s.Db.QueryRow(stmt, userID).Scan(&count); err != nil {
+		return nil, err
+	}
+
+	return count, nil
+}
+
+func (s *PostgresStore) IsConversationMuted(userID, otherUserID int) (bool, error) {
+	stmt := `
+	SELECT EXISTS (
+		SELECT 1 FROM conversation_settings
+		WHERE user_id = $1 AND other_user_id = $2 AND muted_until > now()
+	);
+	`
+
+	var muted bool
+	if err := s.Db.QueryRow(stmt, userID, otherUserID).Scan(&muted); err != nil {
+		return false, err
+	}
+
+	return muted, nil
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+)
+
+// GetNotificationPreferences returns the defaults overridden by the preferences the user has changed
+func (s *PostgresStore) GetNotificationPreferences(userID int) (models.NotificationPreferences, error) {
+	stmt := "SELECT type, channel, enabled FROM notification_preferences WHERE user_id = $1;"
+
+	rows, err := s.Db.Query(stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	preferences := models.DefaultNotificationPreferences()
+	for rows.Next() {
+		var notificationType, channel string
+		var enabled bool
+		if err := rows.Scan(&notificationType, &channel, &enabled); err != nil {
+			return nil, err
+		}
+
+		// Ignore types or channels that do not exist anymore
+		if _, ok := preferences[notificationType][channel]; ok {
+			preferences[notificationType][channel] = enabled
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return preferences, nil
+}
+
+func (s *PostgresStore) UpdateNotificationPreferences(userID int, preferences models.NotificationPreferences) error {
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt := `
+	INSERT INTO notification_preferences (user_id, type, channel, enabled)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (user_id, type, channel) DO UPDATE 
+	SET enabled = EXCLUDED.enabled, updated_at = now();
+	`
+
+	for notificationType, channels := range preferences {
+		for channel, enabled := range channels {
+			if _, err := tx.Exec(stmt, userID, notificationType, channel, enabled); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgresStore) IsNotificationEnabled(userID int, notificationType, channel string) (bool, error) {
+	stmt := "SELECT enabled FROM notification_preferences WHERE user_id = $1 AND type = $2 AND channel = $3;"
+
+	var enabled bool
+	err := s.Db.QueryRow(stmt, userID, notificationType, channel).Scan(&enabled)
+	if err == sql.ErrNoRows {
+		return models.DefaultNotificationPreferences()[notificationType][channel], nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return enabled, nil
+}
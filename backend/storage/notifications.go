@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/lib/pq"
+)
+
+// notificationGroupKey identifies the activities that are aggregated in the same notification
+func notificationGroupKey(n *models.NotificationReq) string {
+	switch {
+	case n.PostID != nil:
+		return fmt.Sprintf("%s:post:%d", n.Type, *n.PostID)
+	case n.EventID != nil:
+		return fmt.Sprintf("%s:event:%d", n.Type, *n.EventID)
+	default:
+		return n.Type
+	}
+}
+
+// CreateNotification records the activity, if the user has an unread notification of the same group
+// the actor is added to it instead of creating a new one. It returns the id of the notification.
+func (s *PostgresStore) CreateNotification(n *models.NotificationReq) (int, error) {
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt := `
+	INSERT INTO notifications (user_id, type, group_key, post_id, comment_id, event_id)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (user_id, group_key) WHERE NOT is_read DO UPDATE 
+	SET updated_at = now(), comment_id = COALESCE(EXCLUDED.comment_id, notifications.comment_id)
+	RETURNING id;
+	`
+
+	var id int
+	err = tx.QueryRow(stmt, n.UserID, n.Type, notificationGroupKey(n), n.PostID, n.CommentID, n.EventID).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	stmtActor := `
+	INSERT INTO notification_actors (notification_id, actor_id)
+	VALUES ($1, $2)
+	ON CONFLICT (notification_id, actor_id) DO UPDATE SET created_at = now();
+	`
+	if _, err := tx.Exec(stmtActor, id, n.ActorID); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (s *PostgresStore) GetNotifications(userID, limit, offset int, onlyUnread bool) ([]models.Notification, int, error) {
+	var totalCount int
+	queryCount := "SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND (NOT $2 OR NOT is_read);"
+	if err := s.Db.QueryRow(queryCount, userID, onlyUnread).Scan(&totalCount); err != nil {
+		return nil, 0, err
+	}
+
+	stmt := `
+	SELECT n.id, n.type, n.post_id, n.comment_id, n.event_id, n.is_read, n.created_at, n.updated_at,
+	       (SELECT COUNT(*) FROM notification_actors na WHERE na.notification_id = n.id)
+	FROM notifications n
+	WHERE n.user_id = $1 AND (NOT $2 OR NOT n.is_read)
+	ORDER BY n.updated_at DESC
+	LIMIT $3 OFFSET $4;
+	`
+
+	rows, err := s.Db.Query(stmt, userID, onlyUnread, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var notifications []models.Notification
+	for rows.Next() {
+		notification := new(models.Notification)
+		if err := rows.Scan(&notification.ID, &notification.Type, &notification.PostID, &notification.CommentID,
+			&notification.EventID, &notification.IsRead, &notification.CreatedAt, &notification.UpdatedAt,
+			&notification.ActorsCount); err != nil {
+			return nil, 0, err
+		}
+		notifications = append(notifications, *notification)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if err := s.fillNotificationActors(notifications); err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, totalCount, nil
+}
+
+func (s *PostgresStore) GetNotificationByID(id int) (*models.Notification, error) {
+	stmt := `
+	SELECT n.id, n.type, n.post_id, n.comment_id, n.event_id, n.is_read, n.created_at, n.updated_at,
+	       (SELECT COUNT(*) FROM notification_actors na WHERE na.notification_id = n.id)
+	FROM notifications n
+	WHERE n.id = $1;
+	`
+
+	notification := new(models.Notification)
+	if err := s.Db.QueryRow(stmt, id).Scan(&notification.ID, &notification.Type, &notification.PostID, &notification.CommentID,
+		&notification.EventID, &notification.IsRead, &notification.CreatedAt, &notification.UpdatedAt,
+		&notification.ActorsCount); err != nil {
+		return nil, err
+	}
+
+	notifications := []models.Notification{*notification}
+	if err := s.fillNotificationActors(notifications); err != nil {
+		return nil, err
+	}
+
+	return &notifications[0], nil
+}
+
+// fillNotificationActors loads the three latest actors of every notification with a single query
+func (s *PostgresStore) fillNotificationActors(notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(notifications))
+	positions := make(map[int]int, len(notifications))
+	for i, notification := range notifications {
+		ids[i] = int64(notification.ID)
+		positions[notification.ID] = i
+	}
+
+	stmt := `
+	SELECT na.notification_id, u.id, u.user_name, u.full_name, u.profile_picture
+	FROM (
+		SELECT notification_id, actor_id, created_at,
+		       ROW_NUMBER() OVER (PARTITION BY notification_id ORDER BY created_at DESC) AS rn
+		FROM notification_actors
+		WHERE notification_id = ANY($1)
+	) na
+	JOIN users u ON u.id = na.actor_id
+	WHERE na.rn <= 3
+	ORDER BY na.notification_id, na.created_at DESC;
+	`
+
+	rows, err := s.Db.Query(stmt, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var notificationID int
+		actor := new(models.PublicUser)
+		if err := rows.Scan(&notificationID, &actor.ID, &actor.UserName, &actor.FullName, &actor.ProfilePicture); err != nil {
+			return err
+		}
+
+		i := positions[notificationID]
+		notifications[i].Actors = append(notifications[i].Actors, *actor)
+	}
+
+	return rows.Err()
+}
+
+func (s *PostgresStore) GetUnreadNotificationsCount(userID int) (*int, error) {
+	var count *int
+	stmt := "SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND NOT is_read;"
+	if err := s.Db.QueryRow(stmt, userID).Scan(&count); err != nil {
+		return nil, err
+	}
+
+	return count, nil
+}
+
+func (s *PostgresStore) ReadNotification(id, userID int) error {
+	res, err := s.Db.Exec("UPDATE notifications SET is_read = true WHERE id = $1 AND user_id = $2;", id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("no notification found to read")
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) ReadAllNotifications(userID int) error {
+	_, err := s.Db.Exec("UPDATE notifications SET is_read = true WHERE user_id = $1 AND NOT is_read;", userID)
+	return err
+}
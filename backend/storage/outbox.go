@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/domain"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/lib/pq"
+)
+
+// Events that fail this many times are left in the outbox for inspection
+const maxOutboxAttempts = 10
+
+// insertOutboxEvent records the event in the transaction of the change that caused it,
+// so the event exists if and only if the change was committed
+func insertOutboxEvent(tx *sql.Tx, e domain.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	stmt := `
+	INSERT INTO outbox_events (event_id, name, payload, created_at)
+	VALUES ($1, $2, $3, $4);
+	`
+
+	_, err = tx.Exec(stmt, e.Meta().ID, e.EventName(), payload, e.Meta().OccurredAt)
+	return err
+}
+
+// ClaimOutboxEvents takes a batch of due events for the lease, the subscribers run outside of any transaction
+// so the rows are not locked while they do their work. Locked and claimed rows are skipped so several relays can
+// run at the same time, and an event is claimed again when the lease ends before it is completed (at-least-once)
+func (s *PostgresStore) ClaimOutboxEvents(limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	stmt := `
+	UPDATE outbox_events
+	SET attempts = attempts + 1, next_attempt_at = now() + make_interval(secs => $3)
+	WHERE id IN (
+		SELECT id FROM outbox_events
+		WHERE published_at IS NULL AND attempts < $1 AND next_attempt_at <= now()
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING id, name, payload, attempts, delivered_to;
+	`
+
+	rows, err := s.Db.Query(stmt, maxOutboxAttempts, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.OutboxEvent
+	for rows.Next() {
+		var e models.OutboxEvent
+		if err := rows.Scan(&e.ID, &e.Name, &e.Payload, &e.Attempts, pq.Array(&e.DeliveredTo)); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// The events keep their order, the returned rows do not
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+
+	return events, nil
+}
+
+// CompleteOutboxEvent marks the event as published, delivered are the subscribers that got it in the last attempt
+func (s *PostgresStore) CompleteOutboxEvent(id int64, delivered []string) error {
+	stmt := `
+	UPDATE outbox_events 
+	SET published_at = now(), last_error = null, delivered_to = delivered_to || $2::text[]
+	WHERE id = $1;
+	`
+
+	_, err := s.Db.Exec(stmt, id, pq.Array(delivered))
+	return err
+}
+
+// FailOutboxEvent records the subscribers that got the event and leaves it for a new attempt at nextAttemptAt
+func (s *PostgresStore) FailOutboxEvent(id int64, delivered []string, eventErr string, nextAttemptAt time.Time) error {
+	stmt := `
+	UPDATE outbox_events 
+	SET last_error = $3, next_attempt_at = $4, delivered_to = delivered_to || $2::text[]
+	WHERE id = $1;
+	`
+
+	_, err := s.Db.Exec(stmt, id, pq.Array(delivered), eventErr, nextAttemptAt)
+	return err
+}
+
+func (s *PostgresStore) PurgePublishedOutboxEvents(before time.Time) (int64, error) {
+	res, err := s.Db.Exec("DELETE FROM outbox_events WHERE published_at IS NOT NULL AND published_at < $1;", before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
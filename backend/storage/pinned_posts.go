@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+)
+
+var (
+	// ErrNotPostOwner is returned when a user tries to pin a post of another user
+	ErrNotPostOwner = errors.New("the post is not yours")
+	// ErrTooManyPinnedPosts is returned when the user already pinned the maximum of posts
+	ErrTooManyPinnedPosts = fmt.Errorf("you can pin at most %d posts", models.MaxPinnedPosts)
+)
+
+// PinPost pins the post on the profile of the user, pinning an already pinned post does nothing
+func (s *PostgresStore) PinPost(userID, postID int) error {
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Locking the user serializes the pins of the same user so the maximum cannot be exceeded
+	if _, err := tx.Exec("SELECT 1 FROM users WHERE id = $1 FOR UPDATE;", userID); err != nil {
+		return err
+	}
+
+	var ownerID int
+	var pinned bool
+	err = tx.QueryRow("SELECT user_id, pinned_at IS NOT NULL FROM posts WHERE id = $1;", postID).Scan(&ownerID, &pinned)
+	if err == sql.ErrNoRows {
+		return errors.New("no post found to pin")
+	}
+	if err != nil {
+		return err
+	}
+	if ownerID != userID {
+		return ErrNotPostOwner
+	}
+	if pinned {
+		return nil
+	}
+
+	var pinnedCount int
+	if err := tx.QueryRow("SELECT count(*) FROM posts WHERE user_id = $1 AND pinned_at IS NOT NULL;", userID).Scan(&pinnedCount); err != nil {
+		return err
+	}
+	if pinnedCount >= models.MaxPinnedPosts {
+		return ErrTooManyPinnedPosts
+	}
+
+	if _, err := tx.Exec("UPDATE posts SET pinned_at = now() WHERE id = $1;", postID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgresStore) UnpinPost(userID, postID int) error {
+	res, err := s.Db.Exec("UPDATE posts SET pinned_at = null WHERE id = $1 AND user_id = $2 AND pinned_at IS NOT NULL;", postID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("no pinned post found to unpin")
+	}
+
+	return nil
+}
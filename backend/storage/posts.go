@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/Marc-Garcia-Coronado/socialNetwork/domain"
 	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
 )
 
@@ -15,24 +16,38 @@ func (s *PostgresStore) CreatePost(post *models.PostReq) (*models.Post, error) {
 		RETURNING id, picture, title, user_id, topic_id, created_at
 	)
 	SELECT ip.id, ip.picture, ip.title, ip.created_at,
-	       u.id AS creator_id, u.user_name, u.full_name, u.email, u.profile_picture,
+	       u.id AS creator_id, u.user_name, u.full_name, u.profile_picture,
 	       t.id AS topic_id, t.name AS topic_name, t.description AS topic_description, t.created_at AS topic_created_at
 	FROM inserted_post ip
 	JOIN users u ON u.id = ip.user_id
 	JOIN topics t ON t.id = ip.topic_id
 	`
 
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	newPost := new(models.Post)
-	err := s.Db.QueryRow(stmt, post.Picture, post.Title, post.UserID, post.TopicID).Scan(
+	err = tx.QueryRow(stmt, post.Picture, post.Title, post.UserID, post.TopicID).Scan(
 		&newPost.ID, &newPost.Picture, &newPost.Title, &newPost.CreatedAt,
 		&newPost.User.ID, &newPost.User.UserName, &newPost.User.FullName,
-		&newPost.User.Email, &newPost.User.ProfilePicture,
+		&newPost.User.ProfilePicture,
 		&newPost.Topic.ID, &newPost.Topic.Name, &newPost.Topic.Description, &newPost.Topic.CreatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := insertOutboxEvent(tx, domain.PostCreated{Base: domain.NewBase(), Post: *newPost}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	return newPost, nil
 }
 
@@ -44,14 +59,14 @@ func (s *PostgresStore) GetUserPosts(id, limit, offset int) ([]models.Post, int,
 	}
 
 	stmt := `
-	SELECT p.id, p.picture, p.title, p.user_id, p.created_at,
-		   u.id AS creator_id, u.user_name, u.full_name, u.email, u.profile_picture, u.is_active, u.role,
+	SELECT p.id, p.picture, p.title, p.user_id, p.created_at, p.pinned_at IS NOT NULL,
+		   u.id AS creator_id, u.user_name, u.full_name, u.profile_picture, u.is_active, u.role,
 	       t.id AS topic_id, t.name AS topic_name, t.description AS topic_description, t.created_at AS topic_created_at
 	FROM posts p
 	JOIN users u ON u.id = p.user_id
 	JOIN topics t ON t.id = p.topic_id
 	WHERE p.user_id = $1
-	ORDER BY p.created_at DESC
+	ORDER BY p.pinned_at DESC NULLS LAST, p.created_at DESC
 	LIMIT $2 OFFSET $3;
 	`
 
@@ -66,9 +81,9 @@ func (s *PostgresStore) GetUserPosts(id, limit, offset int) ([]models.Post, int,
 	for rows.Next() {
 		var newPost models.Post
 		if err := rows.Scan(
-			&newPost.ID, &newPost.Picture, &newPost.Title, &newPost.User.ID, &newPost.CreatedAt,
+			&newPost.ID, &newPost.Picture, &newPost.Title, &newPost.User.ID, &newPost.CreatedAt, &newPost.Pinned,
 			&newPost.User.ID, &newPost.User.UserName, &newPost.User.FullName,
-			&newPost.User.Email, &newPost.User.ProfilePicture, &newPost.User.IsActive, &newPost.User.Role,
+			&newPost.User.ProfilePicture, &newPost.User.IsActive, &newPost.User.Role,
 			&newPost.Topic.ID, &newPost.Topic.Name, &newPost.Topic.Description, &newPost.Topic.CreatedAt,
 		); err != nil {
 			return nil, 0, err
@@ -101,7 +116,7 @@ func (s *PostgresStore) UpdatePost(post map[string]any, postID int) (*models.Pos
 	}
 
 	// Remover la última coma y espacio, y agregar la cláusula WHERE
-	query = query[:len(query)-2] + " WHERE id = $%d RETURNING *;"
+	query = query[:len(query)-2] + " WHERE id = $%d RETURNING id, picture, title, user_id, topic_id, created_at;"
 	args = append(args, postID)
 
 	// Formatear el índice del parámetro de la cláusula WHERE
@@ -150,3 +165,12 @@ func (s *PostgresStore) GetUserPostsCount(userID int) (*int, error) {
 
 	return count, nil
 }
+
+func (s *PostgresStore) GetPostOwnerID(postID int) (int, error) {
+	var userID int
+	if err := s.Db.QueryRow("SELECT user_id FROM posts WHERE id = $1;", postID).Scan(&userID); err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,25 @@
+package storage
+
+import "github.com/Marc-Garcia-Coronado/socialNetwork/models"
+
+// GetProfileCounts returns all the counts of the profile of the user with a single query
+func (s *PostgresStore) GetProfileCounts(userID int) (*models.ProfileCounts, error) {
+	stmt := `
+	SELECT
+		(SELECT count(*) FROM user_follow_user WHERE user_followed_id = $1),
+		(SELECT count(*) FROM user_follow_user WHERE user_following_id = $1),
+		(SELECT count(*) FROM posts WHERE user_id = $1),
+		(SELECT count(*) FROM events WHERE creator_id = $1),
+		(SELECT count(*) FROM user_event WHERE user_id = $1),
+		(SELECT count(*) FROM topics_user WHERE user_id = $1);
+	`
+
+	counts := new(models.ProfileCounts)
+	err := s.Db.QueryRow(stmt, userID).Scan(&counts.Followers, &counts.Follows, &counts.Posts, &counts.Events,
+		&counts.SubscribedEvents, &counts.Topics)
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
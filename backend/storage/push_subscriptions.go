@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+)
+
+// ErrPushSubscriptionTaken is returned when the endpoint is already registered by another user
+var ErrPushSubscriptionTaken = errors.New("the push subscription belongs to another user")
+
+// SavePushSubscription stores the subscription, registering it again updates the keys. An endpoint registered by
+// another user is not taken over, it has to be deleted first
+func (s *PostgresStore) SavePushSubscription(userID int, sub *models.PushSubscriptionReq, userAgent string) (*models.PushSubscription, error) {
+	stmt := `
+	INSERT INTO push_subscriptions (user_id, endpoint, p256dh, auth, user_agent)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (endpoint) DO UPDATE 
+	SET p256dh = EXCLUDED.p256dh, auth = EXCLUDED.auth, user_agent = EXCLUDED.user_agent
+	WHERE push_subscriptions.user_id = EXCLUDED.user_id
+	RETURNING id, user_id, endpoint, p256dh, auth, COALESCE(user_agent, ''), created_at;
+	`
+
+	saved := new(models.PushSubscription)
+	err := s.Db.QueryRow(stmt, userID, sub.Endpoint, sub.Keys.P256dh, sub.Keys.Auth, userAgent).Scan(
+		&saved.ID, &saved.UserID, &saved.Endpoint, &saved.Keys.P256dh, &saved.Keys.Auth, &saved.UserAgent, &saved.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrPushSubscriptionTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return saved, nil
+}
+
+func (s *PostgresStore) GetPushSubscriptions(userID int) ([]models.PushSubscription, error) {
+	stmt := `
+	SELECT id, user_id, endpoint, p256dh, auth, COALESCE(user_agent, ''), created_at
+	FROM push_subscriptions
+	WHERE user_id = $1
+	ORDER BY created_at DESC;
+	`
+
+	rows, err := s.Db.Query(stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []models.PushSubscription
+	for rows.Next() {
+		var sub models.PushSubscription
+		if err := rows.Scan(&sub.ID, &sub.UserID, &sub.Endpoint, &sub.Keys.P256dh, &sub.Keys.Auth, &sub.UserAgent, &sub.CreatedAt); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+func (s *PostgresStore) DeletePushSubscription(id, userID int) error {
+	res, err := s.Db.Exec("DELETE FROM push_subscriptions WHERE id = $1 AND user_id = $2;", id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("no push subscription found to delete")
+	}
+
+	return nil
+}
+
+// DeletePushSubscriptionByEndpoint is used when the push service says the subscription is gone
+func (s *PostgresStore) DeletePushSubscriptionByEndpoint(endpoint string) error {
+	_, err := s.Db.Exec("DELETE FROM push_subscriptions WHERE endpoint = $1;", endpoint)
+	return err
+}
@@ -0,0 +1,73 @@
+package storage
+
+import "github.com/Marc-Garcia-Coronado/socialNetwork/models"
+
+// GetRelationship returns how the viewer is related with the user in both directions with a single query
+func (s *PostgresStore) GetRelationship(viewerID, userID int) (*models.Relationship, error) {
+	stmt := `
+	SELECT
+		EXISTS (SELECT 1 FROM user_follow_user WHERE user_following_id = $1 AND user_followed_id = $2),
+		EXISTS (SELECT 1 FROM user_follow_user WHERE user_following_id = $2 AND user_followed_id = $1),
+		EXISTS (SELECT 1 FROM follow_requests WHERE requester_id = $1 AND target_id = $2),
+		EXISTS (SELECT 1 FROM follow_requests WHERE requester_id = $2 AND target_id = $1),
+		EXISTS (SELECT 1 FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2),
+		EXISTS (SELECT 1 FROM user_blocks WHERE blocker_id = $2 AND blocked_id = $1),
+		EXISTS (SELECT 1 FROM user_mutes WHERE user_id = $1 AND muted_user_id = $2);
+	`
+
+	relationship := &models.Relationship{UserID: userID}
+	err := s.Db.QueryRow(stmt, viewerID, userID).Scan(&relationship.Following, &relationship.FollowedBy,
+		&relationship.Requested, &relationship.RequestedBy, &relationship.Blocked, &relationship.BlockedBy, &relationship.Muted)
+	if err != nil {
+		return nil, err
+	}
+	relationship.Mutual = relationship.Following && relationship.FollowedBy
+
+	return relationship, nil
+}
+
+// knownFollowersFilter matches the users followed by the viewer ($1) that follow the user ($2)
+const knownFollowersFilter = `
+	FROM user_follow_user mine
+	JOIN user_follow_user theirs ON theirs.user_following_id = mine.user_followed_id AND theirs.user_followed_id = $2
+	JOIN users u ON u.id = mine.user_followed_id
+	WHERE mine.user_following_id = $1
+	AND NOT EXISTS (
+		SELECT 1 FROM user_blocks b
+		WHERE (b.blocker_id = $1 AND b.blocked_id = u.id) OR (b.blocker_id = u.id AND b.blocked_id = $1)
+	)`
+
+// GetKnownFollowers returns the users followed by the viewer that follow the user, the most recent follows first
+func (s *PostgresStore) GetKnownFollowers(viewerID, userID, limit, offset int) ([]models.PublicUser, int, error) {
+	var totalCount int
+	if err := s.Db.QueryRow("SELECT count(*)"+knownFollowersFilter+";", viewerID, userID).Scan(&totalCount); err != nil {
+		return nil, 0, err
+	}
+
+	stmt := `
+	SELECT u.id, u.user_name, u.full_name, u.profile_picture, u.role` + knownFollowersFilter + `
+	ORDER BY theirs.followed_at DESC
+	LIMIT $3 OFFSET $4;
+	`
+
+	rows, err := s.Db.Query(stmt, viewerID, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []models.PublicUser{}
+	for rows.Next() {
+		var user models.PublicUser
+		if err := rows.Scan(&user.ID, &user.UserName, &user.FullName, &user.ProfilePicture, &user.Role); err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return users, totalCount, nil
+}
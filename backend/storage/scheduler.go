@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+)
+
+// RunScheduledTask runs the task for the slot if this instance wins the advisory lock of the task
+// and no other instance already ran that slot. It returns false when the task was not run here
+func (s *PostgresStore) RunScheduledTask(name string, slot time.Time, run func() error) (bool, error) {
+	ctx := context.Background()
+
+	// Session advisory locks belong to a connection, so the same one is used to lock and unlock
+	conn, err := s.Db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	lockKey := "scheduler:" + name
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtext($1));", lockKey).Scan(&locked); err != nil {
+		return false, err
+	}
+	if !locked {
+		return false, nil
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock(hashtext($1));", lockKey)
+
+	stmtStart := `
+	INSERT INTO scheduled_tasks (name, last_scheduled_for, last_started_at, last_status)
+	VALUES ($1, $2, now(), 'running')
+	ON CONFLICT (name) DO UPDATE 
+	SET last_scheduled_for = EXCLUDED.last_scheduled_for, last_started_at = now(), last_status = 'running'
+	WHERE scheduled_tasks.last_scheduled_for IS NULL OR scheduled_tasks.last_scheduled_for < EXCLUDED.last_scheduled_for;
+	`
+
+	res, err := conn.ExecContext(ctx, stmtStart, name, slot)
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected, err := res.RowsAffected(); err != nil || rowsAffected == 0 {
+		return false, err
+	}
+
+	start := time.Now()
+	runErr := run()
+
+	status := "success"
+	var lastError *string
+	if runErr != nil {
+		status = "failed"
+		msg := runErr.Error()
+		lastError = &msg
+	}
+
+	stmtFinish := `
+	UPDATE scheduled_tasks
+	SET last_finished_at = now(), last_status = $2, last_error = $3, last_duration_ms = $4, runs_count = runs_count + 1
+	WHERE name = $1;
+	`
+
+	if _, err := conn.ExecContext(ctx, stmtFinish, name, status, lastError, time.Since(start).Milliseconds()); err != nil {
+		return true, err
+	}
+
+	return true, runErr
+}
+
+func (s *PostgresStore) GetScheduledTasks() ([]models.ScheduledTask, error) {
+	stmt := `
+	SELECT name, last_scheduled_for, last_started_at, last_finished_at, last_status, last_error, last_duration_ms, runs_count
+	FROM scheduled_tasks
+	ORDER BY name;
+	`
+
+	rows, err := s.Db.Query(stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []models.ScheduledTask
+	for rows.Next() {
+		var task models.ScheduledTask
+		err := rows.Scan(&task.Name, &task.LastScheduledFor, &task.LastStartedAt, &task.LastFinishedAt,
+			&task.LastStatus, &task.LastError, &task.LastDurationMs, &task.RunsCount)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// GetDueEventReminders returns the subscriptions not reminded yet of events that start before until
+func (s *PostgresStore) GetDueEventReminders(until time.Time) ([]models.EventReminder, error) {
+	stmt := `
+	SELECT ue.user_id, e.id, e.creator_id
+	FROM user_event ue
+	JOIN events e ON e.id = ue.event_id
+	WHERE ue.reminded_at IS NULL AND e.date > now() AND e.date <= $1
+	ORDER BY e.date;
+	`
+
+	rows, err := s.Db.Query(stmt, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reminders []models.EventReminder
+	for rows.Next() {
+		var reminder models.EventReminder
+		if err := rows.Scan(&reminder.UserID, &reminder.EventID, &reminder.CreatorID); err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+func (s *PostgresStore) MarkEventReminded(userID, eventID int) error {
+	_, err := s.Db.Exec("UPDATE user_event SET reminded_at = now() WHERE user_id = $1 AND event_id = $2;", userID, eventID)
+	return err
+}
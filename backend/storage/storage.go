@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
 )
@@ -13,19 +14,48 @@ type Storage interface {
 	Login(username, password string) (*models.User, error)
 	GetUserByID(id int) (*models.User, error)
 	GetUserByUserName(user_name string) (*models.User, error)
-	SearchUsers(query string, limit int) ([]*models.User, error)
+	SearchUsers(query string, viewerID, limit, offset int) ([]models.PublicUser, int, error)
 	CreateUser(user *models.User) (*models.User, error)
 	UpdateUser(user map[string]any, userID int) (*models.User, error)
 	DeleteUser(id int) error
+	GetProfileCounts(userID int) (*models.ProfileCounts, error)
+
+	// Athlete Profile methods
+	GetAthleteProfile(userID int) (*models.AthleteProfile, error)
+	UpdateAthleteProfile(userID int, req *models.AthleteProfileReq) (*models.AthleteProfile, error)
 
 	// User Follow methods
-	FollowUser(userToFollowID, userID int) error
+	FollowUser(userToFollowID, userID int) (string, error)
 	UnfollowUser(userToFollowID, userID int) error
-	GetFollowers(id, limit, offset int) ([]models.User, int, error)
-	GetFollows(id, limit, offset int) ([]models.User, int, error)
+	GetFollowers(id, limit, offset int) ([]models.PublicUser, int, error)
+	GetFollows(id, limit, offset int) ([]models.PublicUser, int, error)
 	GetCountFollowers(id int) (*int, error)
 	GetCountFollows(id int) (*int, error)
 	CheckIfFollowing(followerID, followedID int) (bool, error)
+	GetRelationship(viewerID, userID int) (*models.Relationship, error)
+	GetKnownFollowers(viewerID, userID, limit, offset int) ([]models.PublicUser, int, error)
+
+	// Follow Requests methods
+	GetAccountPrivacy(userID int) (bool, error)
+	SetAccountPrivacy(userID int, isPrivate bool) error
+	CanViewProfile(userID, viewerID int) (bool, error)
+	GetFollowRequests(userID, limit, offset int) ([]models.FollowRequest, int, error)
+	GetSentFollowRequests(userID, limit, offset int) ([]models.FollowRequest, int, error)
+	ApproveFollowRequest(requesterID, userID int) error
+	DeleteFollowRequest(requesterID, targetID int) error
+
+	// Blocks and Mutes methods
+	BlockUser(userID, blockedID int) error
+	UnblockUser(userID, blockedID int) error
+	GetBlockedUsers(userID int) ([]models.PublicUser, error)
+	IsBlocked(userID, otherUserID int) (bool, error)
+	MuteUser(userID, mutedID int) error
+	UnmuteUser(userID, mutedID int) error
+	GetMutedUsers(userID int) ([]models.PublicUser, error)
+
+	// Follow Suggestions methods
+	GetFollowSuggestions(userID, limit int) ([]models.FollowSuggestion, error)
+	DismissSuggestion(userID, dismissedID int) error
 
 	// Topics methods
 	GetTopics() ([]models.Topic, error)
@@ -46,6 +76,9 @@ type Storage interface {
 	UpdatePost(post map[string]any, postID int) (*models.Post, error)
 	DeletePost(id int) error
 	GetUserPostsCount(userID int) (*int, error)
+	GetPostOwnerID(postID int) (int, error)
+	PinPost(userID, postID int) error
+	UnpinPost(userID, postID int) error
 
 	// Events methods
 	CreateEvent(event *models.EventReq) (*models.EventWithUser, error)
@@ -58,6 +91,7 @@ type Storage interface {
 	GetAllEventsCount() (*int, error)
 	GetAllEventsByTopicCount(topicID int) (*int, error)
 	GetUserEventsCount(userID int) (*int, error)
+	GetEventCreatorID(eventID int) (int, error)
 
 	// Subscription to Events methods
 	SubscribeEvent(eventID, userID int) error
@@ -67,7 +101,7 @@ type Storage interface {
 
 	// Comments methods
 	CreateComment(comment *models.CommentReq) (*models.Comment, error)
-	GetPostComments(postID, limit, offset int) ([]models.Comment, int, error)
+	GetPostComments(postID, viewerID, limit, offset int) ([]models.Comment, int, error)
 	GetPostCommentsCount(postID int) (*int, error)
 	DeleteComment(id int) error
 	GetIfUserOwnsComment(commentID, userID int) bool
@@ -91,9 +125,114 @@ type Storage interface {
 	// Messages methods
 	SaveMessage(message *models.MessageReq) (*models.Message, error)
 	GetConversationMessages(from, to int) ([]models.Message, error)
-	GetUserConversations(userID int) ([]models.User, error)
+	GetUserConversations(userID int, filter string) ([]models.Conversation, error)
+	PinConversation(userID, otherUserID int, pinned bool) error
+	ArchiveConversation(userID, otherUserID int, archived bool) error
+	MuteConversation(userID, otherUserID int, until *time.Time) error
 	ReadConversationMessages(from, to int) error
 	GetNotReadedConversationMessages(from, to int) (int, error)
+
+	// Disappearing Messages methods
+	GetConversationRetention(userID, otherUserID int) (*models.ConversationRetention, error)
+	SetConversationRetention(userID, otherUserID, retentionSeconds int, label string) (*models.Message, error)
+	PurgeExpiredMessages(now time.Time) (int64, error)
+
+	// Notifications methods
+	CreateNotification(n *models.NotificationReq) (int, error)
+	GetNotifications(userID, limit, offset int, onlyUnread bool) ([]models.Notification, int, error)
+	GetNotificationByID(id int) (*models.Notification, error)
+	GetUnreadNotificationsCount(userID int) (*int, error)
+	ReadNotification(id, userID int) error
+	ReadAllNotifications(userID int) error
+
+	// Notification Preferences methods
+	GetNotificationPreferences(userID int) (models.NotificationPreferences, error)
+	UpdateNotificationPreferences(userID int, preferences models.NotificationPreferences) error
+	IsNotificationEnabled(userID int, notificationType, channel string) (bool, error)
+
+	// Email Digest methods
+	GetDigestSettings(userID int) (*models.DigestSettings, error)
+	UpdateDigestSettings(userID int, frequency string) (*models.DigestSettings, error)
+	GetDueDigestRecipients(now time.Time) ([]models.DigestRecipient, error)
+	MarkDigestSent(userID int, sentAt time.Time) error
+	GetNewFollowers(userID int, since time.Time, limit int) ([]models.PublicUser, error)
+	GetTopFeedPosts(userID int, since time.Time, limit int) ([]models.Post, error)
+	GetUpcomingSubscribedEvents(userID int, limit int) ([]models.SubscribedEvent, error)
+	GetUnreadMessagesCount(userID int) (*int, error)
+
+	// Push Subscription methods
+	SavePushSubscription(userID int, sub *models.PushSubscriptionReq, userAgent string) (*models.PushSubscription, error)
+	GetPushSubscriptions(userID int) ([]models.PushSubscription, error)
+	DeletePushSubscription(id, userID int) error
+	DeletePushSubscriptionByEndpoint(endpoint string) error
+	IsConversationMuted(userID, otherUserID int) (bool, error)
+
+	// Badge methods
+	CreateBadge(badge *models.BadgeReq) (*models.Badge, error)
+	GetBadges() ([]models.Badge, error)
+	DeleteBadge(id int) error
+	GetUserBadges(userID int) ([]models.UserBadge, error)
+	AwardBadge(userID, badgeID, adminID int) error
+	RevokeBadge(userID, badgeID int) error
+	AwardRuleBadges(userID int) (int64, error)
+	AwardAllRuleBadges() (int64, error)
+
+	// Webhook methods
+	CreateWebhook(userID *int, webhook *models.WebhookReq, secret string) (*models.Webhook, error)
+	GetWebhooks(userID *int) ([]models.Webhook, error)
+	GetWebhookByID(id int, userID *int) (*models.Webhook, error)
+	DeleteWebhook(id int, userID *int) error
+	GetWebhooksForEvent(event string, userIDs []int, topicID *int) ([]models.Webhook, error)
+	GetActiveWebhook(id int) (*models.Webhook, error)
+	SaveWebhookDelivery(delivery *models.WebhookDelivery) error
+	GetWebhookDeliveries(webhookID, limit, offset int) ([]models.WebhookDelivery, int, error)
+
+	// Outbox methods
+	ClaimOutboxEvents(limit int, lease time.Duration) ([]models.OutboxEvent, error)
+	CompleteOutboxEvent(id int64, delivered []string) error
+	FailOutboxEvent(id int64, delivered []string, eventErr string, nextAttemptAt time.Time) error
+	PurgePublishedOutboxEvents(before time.Time) (int64, error)
+
+	// Job Queue methods
+	EnqueueJob(jobType string, payload []byte, runAt time.Time, maxAttempts int) (int64, error)
+	ClaimJob(types []string) (*models.Job, error)
+	HeartbeatJob(id int64, attempts int) error
+	CompleteJob(id int64, attempts int) error
+	RetryJob(id int64, attempts int, jobErr string, runAt time.Time) error
+	KillJob(id int64, attempts int, jobErr string) error
+	RescueStaleJobs(lockedBefore time.Time) (int64, error)
+	GetJobs(status string, limit, offset int) ([]models.Job, int, error)
+	RequeueDeadJob(id int64) error
+	PurgeFinishedJobs(before time.Time) (int64, error)
+
+	// Scheduler methods
+	RunScheduledTask(name string, slot time.Time, run func() error) (bool, error)
+	GetScheduledTasks() ([]models.ScheduledTask, error)
+	GetDueEventReminders(until time.Time) ([]models.EventReminder, error)
+	MarkEventReminded(userID, eventID int) error
+
+	// Message Reactions methods
+	AddMessageReaction(messageID, userID int, emoji string) error
+	RemoveMessageReaction(messageID, userID int, emoji string) error
+	GetMessageParticipants(messageID int) (int, int, error)
+
+	// Encryption Keys methods
+	PublishKeys(userID int, deviceID string, keys *models.PublishKeysReq) error
+	UploadPreKeys(userID int, deviceID string, preKeys []models.OneTimePreKey) error
+	GetUserDevices(userID int) ([]models.Device, error)
+	DeleteDevice(userID int, deviceID string) error
+	GetKeyBundles(requesterID, userID int) ([]models.KeyBundle, error)
+	GetConversationCiphertexts(userID, otherUserID int, deviceID string) (map[int]string, error)
+
+	// Message Requests methods
+	GetMessageDelivery(senderID, receiverID int) (string, error)
+	CreateMessageRequest(senderID, receiverID int) error
+	GetMessageRequests(userID int) ([]models.MessageRequest, error)
+	AcceptMessageRequest(senderID, receiverID int) error
+	DeclineMessageRequest(senderID, receiverID int) error
+	BlockMessageRequest(senderID, receiverID int) error
+	GetMessagePrivacy(userID int) (string, error)
+	UpdateMessagePrivacy(userID int, privacy string) error
 }
 
 type PostgresStore struct {
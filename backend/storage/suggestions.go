@@ -0,0 +1,87 @@
+package storage
+
+import "github.com/Marc-Garcia-Coronado/socialNetwork/models"
+
+// GetFollowSuggestions ranks the users followed by the people the user follows, the users with the same topics or
+// events and the active users. The users already followed, requested, blocked or dismissed are left out
+func (s *PostgresStore) GetFollowSuggestions(userID, limit int) ([]models.FollowSuggestion, error) {
+	stmt := `
+	WITH signals AS (
+		SELECT f2.user_followed_id AS user_id, 1 AS mutual_follows, 0 AS shared_topics, 0 AS shared_events
+		FROM user_follow_user f1
+		JOIN user_follow_user f2 ON f2.user_following_id = f1.user_followed_id
+		WHERE f1.user_following_id = $1
+		UNION ALL
+		SELECT other.user_id, 0, 1, 0
+		FROM topics_user mine
+		JOIN topics_user other ON other.topic_id = mine.topic_id
+		WHERE mine.user_id = $1
+		UNION ALL
+		SELECT other.user_id, 0, 0, 1
+		FROM user_event mine
+		JOIN user_event other ON other.event_id = mine.event_id
+		WHERE mine.user_id = $1
+		UNION ALL
+		SELECT DISTINCT p.user_id, 0, 0, 0
+		FROM posts p
+		WHERE p.created_at > now() - interval '30 days'
+	), candidates AS (
+		SELECT c.user_id, sum(c.mutual_follows) AS mutual_follows, sum(c.shared_topics) AS shared_topics,
+		       sum(c.shared_events) AS shared_events,
+		       (SELECT count(*) FROM posts p WHERE p.user_id = c.user_id AND p.created_at > now() - interval '30 days') AS recent_posts
+		FROM signals c
+		WHERE c.user_id <> $1
+		AND NOT EXISTS (SELECT 1 FROM user_follow_user f WHERE f.user_following_id = $1 AND f.user_followed_id = c.user_id)
+		AND NOT EXISTS (SELECT 1 FROM follow_requests fr WHERE fr.requester_id = $1 AND fr.target_id = c.user_id)
+		AND NOT EXISTS (
+			SELECT 1 FROM user_blocks b
+			WHERE (b.blocker_id = $1 AND b.blocked_id = c.user_id) OR (b.blocker_id = c.user_id AND b.blocked_id = $1)
+		)
+		AND NOT EXISTS (SELECT 1 FROM dismissed_suggestions d WHERE d.user_id = $1 AND d.dismissed_user_id = c.user_id)
+		GROUP BY c.user_id
+	)
+	SELECT u.id, u.user_name, u.full_name, u.profile_picture, u.role,
+	       c.mutual_follows, c.shared_topics, c.shared_events, c.recent_posts
+	FROM candidates c
+	JOIN users u ON u.id = c.user_id
+	WHERE u.is_active
+	ORDER BY 3 * c.mutual_follows + 2 * LEAST(c.shared_topics, 5) + 2 * LEAST(c.shared_events, 5) + 0.2 * LEAST(c.recent_posts, 10) DESC,
+	         u.id
+	LIMIT $2;
+	`
+
+	rows, err := s.Db.Query(stmt, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var suggestions []models.FollowSuggestion
+	for rows.Next() {
+		var suggestion models.FollowSuggestion
+		user := &suggestion.User
+		if err := rows.Scan(&user.ID, &user.UserName, &user.FullName, &user.ProfilePicture, &user.Role,
+			&suggestion.MutualFollows, &suggestion.SharedTopics, &suggestion.SharedEvents, &suggestion.RecentPosts); err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return suggestions, nil
+}
+
+// DismissSuggestion stops suggesting the dismissed user to the user
+func (s *PostgresStore) DismissSuggestion(userID, dismissedID int) error {
+	stmt := `
+	INSERT INTO dismissed_suggestions (user_id, dismissed_user_id)
+	VALUES ($1, $2)
+	ON CONFLICT (user_id, dismissed_user_id) DO NOTHING;
+	`
+
+	_, err := s.Db.Exec(stmt, userID, dismissedID)
+	return err
+}
@@ -25,6 +25,444 @@ func (s *PostgresStore) createMessagesTable() error {
 	return nil
 }
 
+func (s *PostgresStore) createMessageRequestsTable() error {
+	queryEnum := `
+	DO $$ 
+	BEGIN 
+		IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'message_request_status') THEN
+			CREATE TYPE message_request_status AS ENUM ('pending', 'accepted', 'blocked');
+		END IF;
+	END $$;`
+
+	queryTable := `
+	CREATE TABLE IF NOT EXISTS message_requests (
+	  id SERIAL PRIMARY KEY,
+	  sender_id INT NOT NULL,
+	  receiver_id INT NOT NULL,
+	  status message_request_status NOT NULL DEFAULT 'pending',
+	  created_at TIMESTAMPTZ DEFAULT now(),
+	  updated_at TIMESTAMPTZ DEFAULT now(),
+
+	  FOREIGN KEY (sender_id) REFERENCES users(id) ON DELETE CASCADE,
+	  FOREIGN KEY (receiver_id) REFERENCES users(id) ON DELETE CASCADE,
+	  UNIQUE (sender_id, receiver_id)
+	);`
+
+	if _, err := s.Db.Exec(queryEnum); err != nil {
+		return err
+	}
+
+	if _, err := s.Db.Exec(queryTable); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) createConversationSettingsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS conversation_settings (
+	  id SERIAL PRIMARY KEY,
+	  user_id INT NOT NULL,
+	  other_user_id INT NOT NULL,
+	  pinned BOOLEAN DEFAULT FALSE,
+	  pinned_at TIMESTAMPTZ DEFAULT null,
+	  archived BOOLEAN DEFAULT FALSE,
+	  muted_until TIMESTAMPTZ DEFAULT null,
+	  -- The messages up to hidden_before are not shown to the user, declined and blocked requests are hidden this way
+	  hidden_before TIMESTAMPTZ DEFAULT null,
+
+	  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	  FOREIGN KEY (other_user_id) REFERENCES users(id) ON DELETE CASCADE,
+	  UNIQUE (user_id, other_user_id)
+	);`
+
+	if _, err := s.Db.Exec(query); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) createMessageReactionsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS message_reactions (
+	  id SERIAL PRIMARY KEY,
+	  message_id INT NOT NULL,
+	  user_id INT NOT NULL,
+	  emoji VARCHAR(32) NOT NULL,
+	  created_at TIMESTAMPTZ DEFAULT now(),
+
+	  FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE,
+	  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	  UNIQUE (message_id, user_id, emoji)
+	);`
+
+	if _, err := s.Db.Exec(query); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) createConversationRetentionTable() error {
+	queryColumns := `
+	ALTER TABLE messages ADD COLUMN IF NOT EXISTS is_system BOOLEAN DEFAULT FALSE;
+	ALTER TABLE messages ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ DEFAULT null;
+	CREATE INDEX IF NOT EXISTS messages_expires_at_idx ON messages (expires_at) WHERE expires_at IS NOT NULL;`
+
+	queryTable := `
+	CREATE TABLE IF NOT EXISTS conversation_retention (
+	  id SERIAL PRIMARY KEY,
+	  user_low_id INT NOT NULL,
+	  user_high_id INT NOT NULL,
+	  retention_seconds INT NOT NULL,
+	  updated_by INT,
+	  updated_at TIMESTAMPTZ DEFAULT now(),
+
+	  FOREIGN KEY (user_low_id) REFERENCES users(id) ON DELETE CASCADE,
+	  FOREIGN KEY (user_high_id) REFERENCES users(id) ON DELETE CASCADE,
+	  FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL,
+	  UNIQUE (user_low_id, user_high_id)
+	);`
+
+	if _, err := s.Db.Exec(queryColumns); err != nil {
+		return err
+	}
+
+	if _, err := s.Db.Exec(queryTable); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) createKeysTables() error {
+	queryDevices := `
+	CREATE TABLE IF NOT EXISTS user_devices (
+	  id SERIAL PRIMARY KEY,
+	  user_id INT NOT NULL,
+	  device_id VARCHAR(64) NOT NULL,
+	  registration_id INT NOT NULL,
+	  identity_key TEXT NOT NULL,
+	  signed_pre_key_id INT NOT NULL,
+	  signed_pre_key TEXT NOT NULL,
+	  signed_pre_key_signature TEXT NOT NULL,
+	  created_at TIMESTAMPTZ DEFAULT now(),
+	  updated_at TIMESTAMPTZ DEFAULT now(),
+
+	  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	  UNIQUE (user_id, device_id)
+	);`
+
+	queryPreKeys := `
+	CREATE TABLE IF NOT EXISTS one_time_pre_keys (
+	  id SERIAL PRIMARY KEY,
+	  device_id INT NOT NULL,
+	  key_id INT NOT NULL,
+	  public_key TEXT NOT NULL,
+
+	  FOREIGN KEY (device_id) REFERENCES user_devices(id) ON DELETE CASCADE,
+	  UNIQUE (device_id, key_id)
+	);`
+
+	queryCiphertexts := `
+	ALTER TABLE messages ADD COLUMN IF NOT EXISTS mode VARCHAR(10) NOT NULL DEFAULT 'plain';
+	CREATE TABLE IF NOT EXISTS message_ciphertexts (
+	  id SERIAL PRIMARY KEY,
+	  message_id INT NOT NULL,
+	  device_id INT NOT NULL,
+	  ciphertext TEXT NOT NULL,
+
+	  FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE,
+	  FOREIGN KEY (device_id) REFERENCES user_devices(id) ON DELETE CASCADE,
+	  UNIQUE (message_id, device_id)
+	);`
+
+	// Every fetch of key bundles consumes pre keys of another user, the recent fetches limit how many a user makes
+	queryFetches := `
+	CREATE TABLE IF NOT EXISTS key_bundle_fetches (
+	  id SERIAL PRIMARY KEY,
+	  requester_id INT NOT NULL,
+	  fetched_at TIMESTAMPTZ DEFAULT now(),
+
+	  FOREIGN KEY (requester_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS key_bundle_fetches_requester_idx ON key_bundle_fetches (requester_id, fetched_at);`
+
+	if _, err := s.Db.Exec(queryDevices); err != nil {
+		return err
+	}
+
+	if _, err := s.Db.Exec(queryPreKeys); err != nil {
+		return err
+	}
+
+	if _, err := s.Db.Exec(queryCiphertexts); err != nil {
+		return err
+	}
+
+	if _, err := s.Db.Exec(queryFetches); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) createNotificationsTables() error {
+	queryNotifications := `
+	CREATE TABLE IF NOT EXISTS notifications (
+	  id SERIAL PRIMARY KEY,
+	  user_id INT NOT NULL,
+	  type VARCHAR(50) NOT NULL,
+	  group_key VARCHAR(100) NOT NULL,
+	  post_id INT NULL,
+	  comment_id INT NULL,
+	  event_id INT NULL,
+	  is_read BOOLEAN DEFAULT FALSE,
+	  created_at TIMESTAMPTZ DEFAULT now(),
+	  updated_at TIMESTAMPTZ DEFAULT now(),
+
+	  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	  FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+	  FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE SET NULL,
+	  FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE
+	);
+	-- Only one unread notification per activity group, new actors are added to it
+	CREATE UNIQUE INDEX IF NOT EXISTS notifications_unread_group_idx ON notifications (user_id, group_key) WHERE NOT is_read;
+	CREATE INDEX IF NOT EXISTS notifications_user_idx ON notifications (user_id, updated_at DESC);`
+
+	queryActors := `
+	CREATE TABLE IF NOT EXISTS notification_actors (
+	  id SERIAL PRIMARY KEY,
+	  notification_id INT NOT NULL,
+	  actor_id INT NOT NULL,
+	  created_at TIMESTAMPTZ DEFAULT now(),
+
+	  FOREIGN KEY (notification_id) REFERENCES notifications(id) ON DELETE CASCADE,
+	  FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE CASCADE,
+	  UNIQUE (notification_id, actor_id)
+	);`
+
+	if _, err := s.Db.Exec(queryNotifications); err != nil {
+		return err
+	}
+
+	if _, err := s.Db.Exec(queryActors); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) createNotificationPreferencesTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS notification_preferences (
+	  id SERIAL PRIMARY KEY,
+	  user_id INT NOT NULL,
+	  type VARCHAR(50) NOT NULL,
+	  channel VARCHAR(20) NOT NULL,
+	  enabled BOOLEAN NOT NULL,
+	  updated_at TIMESTAMPTZ DEFAULT now(),
+
+	  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	  UNIQUE (user_id, type, channel)
+	);`
+
+	if _, err := s.Db.Exec(query); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) createEmailDigestsTable() error {
+	queryEnum := `
+	DO $$ 
+	BEGIN 
+		IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'digest_frequency') THEN
+			CREATE TYPE digest_frequency AS ENUM ('off', 'daily', 'weekly');
+		END IF;
+	END $$;`
+
+	queryTable := `
+	CREATE TABLE IF NOT EXISTS email_digests (
+	  id SERIAL PRIMARY KEY,
+	  user_id INT UNIQUE NOT NULL,
+	  frequency digest_frequency NOT NULL DEFAULT 'off',
+	  last_sent_at TIMESTAMPTZ DEFAULT null,
+	  updated_at TIMESTAMPTZ DEFAULT now(),
+
+	  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);`
+
+	if _, err := s.Db.Exec(queryEnum); err != nil {
+		return err
+	}
+
+	if _, err := s.Db.Exec(queryTable); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) createPushSubscriptionsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS push_subscriptions (
+	  id SERIAL PRIMARY KEY,
+	  user_id INT NOT NULL,
+	  endpoint TEXT UNIQUE NOT NULL,
+	  p256dh TEXT NOT NULL,
+	  auth TEXT NOT NULL,
+	  user_agent VARCHAR(255),
+	  created_at TIMESTAMPTZ DEFAULT now(),
+
+	  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);`
+
+	if _, err := s.Db.Exec(query); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) createWebhooksTables() error {
+	queryWebhooks := `
+	CREATE TABLE IF NOT EXISTS webhooks (
+	  id SERIAL PRIMARY KEY,
+	  user_id INT DEFAULT null,
+	  url TEXT NOT NULL,
+	  secret VARCHAR(64) NOT NULL,
+	  events TEXT[] NOT NULL,
+	  -- The posts and events of a topic can be delivered to webhooks that are not of their users
+	  topic_id INT DEFAULT null,
+	  is_active BOOLEAN DEFAULT TRUE,
+	  created_at TIMESTAMPTZ DEFAULT now(),
+
+	  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	  FOREIGN KEY (topic_id) REFERENCES topics(id) ON DELETE CASCADE
+	);`
+
+	queryDeliveries := `
+	CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	  id SERIAL PRIMARY KEY,
+	  webhook_id INT NOT NULL,
+	  delivery_id VARCHAR(64) NOT NULL,
+	  event VARCHAR(50) NOT NULL,
+	  payload JSONB NOT NULL,
+	  attempt INT NOT NULL,
+	  status_code INT DEFAULT null,
+	  error TEXT DEFAULT null,
+	  success BOOLEAN NOT NULL,
+	  duration_ms BIGINT NOT NULL,
+	  delivered_at TIMESTAMPTZ DEFAULT now(),
+
+	  FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_idx ON webhook_deliveries (webhook_id, delivered_at DESC);`
+
+	if _, err := s.Db.Exec(queryWebhooks); err != nil {
+		return err
+	}
+
+	if _, err := s.Db.Exec(queryDeliveries); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) createOutboxTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS outbox_events (
+	  id BIGSERIAL PRIMARY KEY,
+	  event_id VARCHAR(64) UNIQUE NOT NULL,
+	  name VARCHAR(50) NOT NULL,
+	  payload JSONB NOT NULL,
+	  attempts INT NOT NULL DEFAULT 0,
+	  last_error TEXT DEFAULT null,
+	  -- Failed events wait with backoff, claimed ones wait for the relay that claimed them
+	  next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	  -- Subscribers that already got the event, a retry only runs the rest
+	  delivered_to TEXT[] NOT NULL DEFAULT '{}',
+	  created_at TIMESTAMPTZ DEFAULT now(),
+	  published_at TIMESTAMPTZ DEFAULT null
+	);
+	CREATE INDEX IF NOT EXISTS outbox_events_pending_idx ON outbox_events (next_attempt_at, id) WHERE published_at IS NULL;`
+
+	if _, err := s.Db.Exec(query); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) createJobsTable() error {
+	queryEnum := `
+	DO $$ 
+	BEGIN 
+		IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'job_status') THEN
+			CREATE TYPE job_status AS ENUM ('pending', 'running', 'done', 'dead');
+		END IF;
+	END $$;`
+
+	queryTable := `
+	CREATE TABLE IF NOT EXISTS jobs (
+	  id BIGSERIAL PRIMARY KEY,
+	  type VARCHAR(100) NOT NULL,
+	  payload JSONB NOT NULL,
+	  status job_status NOT NULL DEFAULT 'pending',
+	  attempts INT NOT NULL DEFAULT 0,
+	  max_attempts INT NOT NULL DEFAULT 5,
+	  run_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	  locked_at TIMESTAMPTZ DEFAULT null,
+	  last_error TEXT DEFAULT null,
+	  created_at TIMESTAMPTZ DEFAULT now(),
+	  updated_at TIMESTAMPTZ DEFAULT now()
+	);
+	CREATE INDEX IF NOT EXISTS jobs_pending_idx ON jobs (run_at) WHERE status = 'pending';`
+
+	if _, err := s.Db.Exec(queryEnum); err != nil {
+		return err
+	}
+
+	if _, err := s.Db.Exec(queryTable); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) createScheduledTasksTable() error {
+	queryTable := `
+	CREATE TABLE IF NOT EXISTS scheduled_tasks (
+	  name VARCHAR(100) PRIMARY KEY,
+	  last_scheduled_for TIMESTAMPTZ DEFAULT null,
+	  last_started_at TIMESTAMPTZ DEFAULT null,
+	  last_finished_at TIMESTAMPTZ DEFAULT null,
+	  last_status VARCHAR(20) DEFAULT null,
+	  last_error TEXT DEFAULT null,
+	  last_duration_ms BIGINT DEFAULT null,
+	  runs_count INT NOT NULL DEFAULT 0
+	);`
+
+	// Subscriptions already reminded of their event
+	queryColumns := `
+	ALTER TABLE user_event ADD COLUMN IF NOT EXISTS reminded_at TIMESTAMPTZ DEFAULT null;`
+
+	if _, err := s.Db.Exec(queryTable); err != nil {
+		return err
+	}
+
+	if _, err := s.Db.Exec(queryColumns); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (s *PostgresStore) createLikesTable() error {
 	query := `
 	CREATE TABLE IF NOT EXISTS likes (
@@ -130,6 +568,15 @@ func (s *PostgresStore) createPostsTable() error {
 		return err
 	}
 
+	// The pinned posts go first on the profile, the most recently pinned first
+	queryPinned := `
+	ALTER TABLE posts ADD COLUMN IF NOT EXISTS pinned_at TIMESTAMPTZ DEFAULT null;
+	CREATE INDEX IF NOT EXISTS posts_pinned_idx ON posts (user_id) WHERE pinned_at IS NOT NULL;`
+
+	if _, err := s.Db.Exec(queryPinned); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -153,6 +600,151 @@ func (s *PostgresStore) createFollowsTables() error {
 	return nil
 }
 
+func (s *PostgresStore) createFollowRequestsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS follow_requests (
+	  id SERIAL PRIMARY KEY,
+	  requester_id INT NOT NULL,
+	  target_id INT NOT NULL,
+	  created_at TIMESTAMPTZ DEFAULT now(),
+
+	  FOREIGN KEY (requester_id) REFERENCES users(id) ON DELETE CASCADE,
+	  FOREIGN KEY (target_id) REFERENCES users(id) ON DELETE CASCADE,
+	  UNIQUE (requester_id, target_id)
+	);
+	CREATE INDEX IF NOT EXISTS follow_requests_target_idx ON follow_requests (target_id, created_at DESC);`
+
+	if _, err := s.Db.Exec(query); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) createBlocksTables() error {
+	queryBlocks := `
+	CREATE TABLE IF NOT EXISTS user_blocks (
+	  id SERIAL PRIMARY KEY,
+	  blocker_id INT NOT NULL,
+	  blocked_id INT NOT NULL,
+	  created_at TIMESTAMPTZ DEFAULT now(),
+
+	  FOREIGN KEY (blocker_id) REFERENCES users(id) ON DELETE CASCADE,
+	  FOREIGN KEY (blocked_id) REFERENCES users(id) ON DELETE CASCADE,
+	  UNIQUE (blocker_id, blocked_id)
+	);
+	-- Blocks are checked in both directions
+	CREATE INDEX IF NOT EXISTS user_blocks_blocked_idx ON user_blocks (blocked_id, blocker_id);`
+
+	queryMutes := `
+	CREATE TABLE IF NOT EXISTS user_mutes (
+	  id SERIAL PRIMARY KEY,
+	  user_id INT NOT NULL,
+	  muted_user_id INT NOT NULL,
+	  created_at TIMESTAMPTZ DEFAULT now(),
+
+	  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	  FOREIGN KEY (muted_user_id) REFERENCES users(id) ON DELETE CASCADE,
+	  UNIQUE (user_id, muted_user_id)
+	);`
+
+	if _, err := s.Db.Exec(queryBlocks); err != nil {
+		return err
+	}
+
+	if _, err := s.Db.Exec(queryMutes); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) createDismissedSuggestionsTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS dismissed_suggestions (
+	  id SERIAL PRIMARY KEY,
+	  user_id INT NOT NULL,
+	  dismissed_user_id INT NOT NULL,
+	  dismissed_at TIMESTAMPTZ DEFAULT now(),
+
+	  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	  FOREIGN KEY (dismissed_user_id) REFERENCES users(id) ON DELETE CASCADE,
+	  UNIQUE (user_id, dismissed_user_id)
+	);`
+
+	if _, err := s.Db.Exec(query); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) createBadgesTables() error {
+	queryBadges := `
+	CREATE TABLE IF NOT EXISTS badges (
+	  id SERIAL PRIMARY KEY,
+	  name VARCHAR(100) UNIQUE NOT NULL,
+	  description TEXT NOT NULL DEFAULT '',
+	  icon VARCHAR(255),
+	  -- The badges without a rule are awarded by the admins
+	  rule VARCHAR(30),
+	  threshold INT,
+	  created_at TIMESTAMPTZ DEFAULT now(),
+
+	  CHECK ((rule IS NULL) = (threshold IS NULL))
+	);`
+
+	queryUserBadges := `
+	CREATE TABLE IF NOT EXISTS user_badges (
+	  id SERIAL PRIMARY KEY,
+	  user_id INT NOT NULL,
+	  badge_id INT NOT NULL,
+	  awarded_by INT,
+	  awarded_at TIMESTAMPTZ DEFAULT now(),
+	  -- Revoked badges keep their row so the rules do not award them again
+	  revoked_at TIMESTAMPTZ DEFAULT null,
+
+	  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	  FOREIGN KEY (badge_id) REFERENCES badges(id) ON DELETE CASCADE,
+	  FOREIGN KEY (awarded_by) REFERENCES users(id) ON DELETE SET NULL,
+	  UNIQUE (user_id, badge_id)
+	);`
+
+	if _, err := s.Db.Exec(queryBadges); err != nil {
+		return err
+	}
+
+	if _, err := s.Db.Exec(queryUserBadges); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) createAthleteProfilesTable() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS athlete_profiles (
+	  user_id INT PRIMARY KEY,
+	  sports JSONB NOT NULL DEFAULT '[]',
+	  home_city VARCHAR(100),
+	  training_times TEXT[] NOT NULL DEFAULT '{}',
+	  height_cm INT,
+	  weight_kg NUMERIC(5, 1),
+	  links JSONB NOT NULL DEFAULT '[]',
+	  -- Only the fields changed by the user, the rest use the default visibility
+	  visibility JSONB NOT NULL DEFAULT '{}',
+	  updated_at TIMESTAMPTZ DEFAULT now(),
+
+	  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);`
+
+	if _, err := s.Db.Exec(query); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (s *PostgresStore) createTopicsUserTable() error {
 	query := `
 	CREATE TABLE IF NOT EXISTS topics_user (
@@ -223,6 +815,43 @@ func (s *PostgresStore) createUsersTable() error {
 		return err
 	}
 
+	queryPrivacyEnum := `
+	DO $$ 
+	BEGIN 
+		IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'message_privacy') THEN
+			CREATE TYPE message_privacy AS ENUM ('everyone', 'followers', 'nobody');
+		END IF;
+	END $$;`
+
+	queryPrivacyColumn := `
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS message_privacy message_privacy NOT NULL DEFAULT 'everyone';`
+
+	if _, err := s.Db.Exec(queryPrivacyEnum); err != nil {
+		return err
+	}
+
+	if _, err := s.Db.Exec(queryPrivacyColumn); err != nil {
+		return err
+	}
+
+	// The posts and follows of private accounts are only visible to their approved followers
+	queryPrivateColumn := `
+	ALTER TABLE users ADD COLUMN IF NOT EXISTS is_private BOOLEAN NOT NULL DEFAULT FALSE;`
+
+	if _, err := s.Db.Exec(queryPrivateColumn); err != nil {
+		return err
+	}
+
+	// The user search ranks the users by the trigram similarity of their names
+	querySearchIndexes := `
+	CREATE EXTENSION IF NOT EXISTS pg_trgm;
+	CREATE INDEX IF NOT EXISTS users_user_name_trgm_idx ON users USING GIN (user_name gin_trgm_ops);
+	CREATE INDEX IF NOT EXISTS users_full_name_trgm_idx ON users USING GIN (full_name gin_trgm_ops);`
+
+	if _, err := s.Db.Exec(querySearchIndexes); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -267,6 +896,78 @@ func (s *PostgresStore) CreateTables() error {
 		log.Println("ERR MESSAGES TABLE")
 		return err
 	}
+	if err := s.createConversationRetentionTable(); err != nil {
+		log.Println("ERR CONVERSATION RETENTION TABLE")
+		return err
+	}
+	if err := s.createKeysTables(); err != nil {
+		log.Println("ERR KEYS TABLES")
+		return err
+	}
+	if err := s.createMessageRequestsTable(); err != nil {
+		log.Println("ERR MESSAGE REQUESTS TABLE")
+		return err
+	}
+	if err := s.createConversationSettingsTable(); err != nil {
+		log.Println("ERR CONVERSATION SETTINGS TABLE")
+		return err
+	}
+	if err := s.createMessageReactionsTable(); err != nil {
+		log.Println("ERR MESSAGE REACTIONS TABLE")
+		return err
+	}
+	if err := s.createNotificationsTables(); err != nil {
+		log.Println("ERR NOTIFICATIONS TABLES")
+		return err
+	}
+	if err := s.createNotificationPreferencesTable(); err != nil {
+		log.Println("ERR NOTIFICATION PREFERENCES TABLE")
+		return err
+	}
+	if err := s.createEmailDigestsTable(); err != nil {
+		log.Println("ERR EMAIL DIGESTS TABLE")
+		return err
+	}
+	if err := s.createPushSubscriptionsTable(); err != nil {
+		log.Println("ERR PUSH SUBSCRIPTIONS TABLE")
+		return err
+	}
+	if err := s.createWebhooksTables(); err != nil {
+		log.Println("ERR WEBHOOKS TABLES")
+		return err
+	}
+	if err := s.createOutboxTable(); err != nil {
+		log.Println("ERR OUTBOX TABLE")
+		return err
+	}
+	if err := s.createJobsTable(); err != nil {
+		log.Println("ERR JOBS TABLE")
+		return err
+	}
+	if err := s.createScheduledTasksTable(); err != nil {
+		log.Println("ERR SCHEDULED TASKS TABLE")
+		return err
+	}
+	if err := s.createFollowRequestsTable(); err != nil {
+		log.Println("ERR FOLLOW REQUESTS TABLE")
+		return err
+	}
+	if err := s.createBlocksTables(); err != nil {
+		log.Println("ERR BLOCKS TABLES")
+		return err
+	}
+	if err := s.createAthleteProfilesTable(); err != nil {
+		log.Println("ERR ATHLETE PROFILES TABLE")
+		return err
+	}
+	if err := s.createDismissedSuggestionsTable(); err != nil {
+		log.Println("ERR DISMISSED SUGGESTIONS TABLE")
+		return err
+	}
+	if err := s.createBadgesTables(); err != nil {
+		log.Println("ERR BADGES TABLES")
+		return err
+	}
 	// TODO: Falta implementar las tablas de Stories, Conversations
 	return nil
 }
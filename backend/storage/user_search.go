@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"strings"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+)
+
+// searchUsersFilter matches the users whose names look like the query ($1) or contain it ($2), the users blocked
+// by or blocking the viewer ($3) are not found
+const searchUsersFilter = `
+	FROM users u
+	WHERE (u.user_name % $1 OR u.full_name % $1 OR $1 <% u.full_name OR u.user_name ILIKE $2 OR u.full_name ILIKE $2)
+	AND NOT EXISTS (
+		SELECT 1 FROM user_blocks b
+		WHERE (b.blocker_id = $3 AND b.blocked_id = u.id) OR (b.blocker_id = u.id AND b.blocked_id = $3)
+	)`
+
+// SearchUsers finds users by user name and full name tolerating typos. The best matches go first and the users
+// the viewer follows or that follow the same topics are boosted
+func (s *PostgresStore) SearchUsers(query string, viewerID, limit, offset int) ([]models.PublicUser, int, error) {
+	query = strings.TrimSpace(query)
+	contains := "%" + escapeLike(query) + "%"
+
+	var totalCount int
+	if err := s.Db.QueryRow("SELECT count(*)"+searchUsersFilter+";", query, contains, viewerID).Scan(&totalCount); err != nil {
+		return nil, 0, err
+	}
+
+	stmt := `
+	SELECT u.id, u.user_name, u.full_name, u.profile_picture, u.role` + searchUsersFilter + `
+	ORDER BY
+		GREATEST(similarity(u.user_name, $1), similarity(u.full_name, $1), word_similarity($1, u.full_name))
+		+ CASE WHEN lower(u.user_name) = lower($1) THEN 1 WHEN u.user_name ILIKE $4 THEN 0.5 ELSE 0 END
+		+ CASE WHEN EXISTS (
+			SELECT 1 FROM user_follow_user f WHERE f.user_following_id = $3 AND f.user_followed_id = u.id
+		) THEN 0.3 ELSE 0 END
+		+ 0.05 * LEAST((
+			SELECT count(*) FROM topics_user tu
+			JOIN topics_user mine ON mine.topic_id = tu.topic_id AND mine.user_id = $3
+			WHERE tu.user_id = u.id
+		), 5) DESC,
+		u.id
+	LIMIT $5 OFFSET $6;
+	`
+
+	rows, err := s.Db.Query(stmt, query, contains, viewerID, escapeLike(query)+"%", limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var users []models.PublicUser
+	for rows.Next() {
+		var user models.PublicUser
+		if err := rows.Scan(&user.ID, &user.UserName, &user.FullName, &user.ProfilePicture, &user.Role); err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return users, totalCount, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern so they are matched literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -36,12 +36,12 @@ func (s *PostgresStore) Login(email, password string) (*models.User, error) {
 	newUser := new(models.User)
 
 	stmt := `
-	SELECT id, user_name, full_name, email, profile_picture, bio, is_active, role, user_since, password_hash 
+	SELECT id, user_name, full_name, email, profile_picture, bio, is_active, is_private, role, user_since, password_hash 
 	FROM users 
 	WHERE email = $1;
 	`
 	err := s.Db.QueryRow(stmt, email).Scan(&newUser.ID, &newUser.UserName, &newUser.FullName, &newUser.Email,
-		&newUser.ProfilePicture, &newUser.Bio, &newUser.IsActive, &newUser.Role, &newUser.UserSince, &hash)
+		&newUser.ProfilePicture, &newUser.Bio, &newUser.IsActive, &newUser.IsPrivate, &newUser.Role, &newUser.UserSince, &hash)
 	if err != nil {
 		return nil, err
 	}
@@ -57,9 +57,9 @@ func (s *PostgresStore) Login(email, password string) (*models.User, error) {
 func (s *PostgresStore) GetUserByID(id int) (*models.User, error) {
 
 	user := new(models.User)
-	stmt := "SELECT id, user_name, full_name, email, profile_picture, bio, is_active, role, user_since FROM users WHERE id = $1"
+	stmt := "SELECT id, user_name, full_name, email, profile_picture, bio, is_active, is_private, role, user_since FROM users WHERE id = $1"
 	if err := s.Db.QueryRow(stmt, id).Scan(&user.ID, &user.UserName, &user.FullName, &user.Email,
-		&user.ProfilePicture, &user.Bio, &user.IsActive, &user.Role, &user.UserSince); err != nil {
+		&user.ProfilePicture, &user.Bio, &user.IsActive, &user.IsPrivate, &user.Role, &user.UserSince); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("user not found")
 		}
@@ -75,13 +75,13 @@ func (s *PostgresStore) GetUserByUserName(user_name string) (*models.User, error
 	}
 
 	user := new(models.User)
-	query := `SELECT id, user_name, full_name, email, profile_picture, bio, is_active, role, user_since 
+	query := `SELECT id, user_name, full_name, email, profile_picture, bio, is_active, is_private, role, user_since 
 			FROM users 
 			WHERE user_name = $1`
 
 	err := s.Db.QueryRow(query, user_name).Scan(
 		&user.ID, &user.UserName, &user.FullName, &user.Email,
-		&user.ProfilePicture, &user.Bio, &user.IsActive, &user.Role, &user.UserSince,
+		&user.ProfilePicture, &user.Bio, &user.IsActive, &user.IsPrivate, &user.Role, &user.UserSince,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -93,33 +93,6 @@ func (s *PostgresStore) GetUserByUserName(user_name string) (*models.User, error
 	return user, nil
 }
 
-func (s *PostgresStore) SearchUsers(query string, limit int) ([]*models.User, error) {
-    stmt := `
-        SELECT id, user_name, full_name, email, profile_picture 
-        FROM users 
-        WHERE user_name ILIKE $1
-        LIMIT $2;
-    `
-
-    rows, err := s.Db.Query(stmt, "%"+query+"%", limit)
-    if err != nil {
-        return nil, err
-    }
-    defer rows.Close()
-
-    var users []*models.User
-    for rows.Next() {
-        user := new(models.User)
-        err := rows.Scan(&user.ID, &user.UserName, &user.FullName, &user.Email, &user.ProfilePicture)
-        if err != nil {
-            return nil, err
-        }
-        users = append(users, user)
-    }
-
-    return users, nil
-}
-
 func (s *PostgresStore) UpdateUser(user map[string]any, userID int) (*models.User, error) {
 
 	// Build dynamic SQL query
@@ -134,13 +107,13 @@ func (s *PostgresStore) UpdateUser(user map[string]any, userID int) (*models.Use
 	}
 
 	stmt = stmt[:len(stmt)-2] // Remove last comma
-	stmt += " WHERE id = $" + strconv.Itoa(i) + " RETURNING id, user_name, full_name, email, profile_picture, bio, is_active, role, user_since"
+	stmt += " WHERE id = $" + strconv.Itoa(i) + " RETURNING id, user_name, full_name, email, profile_picture, bio, is_active, is_private, role, user_since"
 	values = append(values, userID)
 
 	updatedUser := new(models.User)
 	// Execute stmt
 	err := s.Db.QueryRow(stmt, values...).Scan(&updatedUser.ID, &updatedUser.UserName, &updatedUser.FullName, &updatedUser.Email,
-		&updatedUser.ProfilePicture, &updatedUser.Bio, &updatedUser.IsActive, &updatedUser.Role, &updatedUser.UserSince)
+		&updatedUser.ProfilePicture, &updatedUser.Bio, &updatedUser.IsActive, &updatedUser.IsPrivate, &updatedUser.Role, &updatedUser.UserSince)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"errors"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/lib/pq"
+)
+
+// The webhooks of the admins have no user and receive the events of every user
+
+func (s *PostgresStore) CreateWebhook(userID *int, webhook *models.WebhookReq, secret string) (*models.Webhook, error) {
+	stmt := `
+	INSERT INTO webhooks (user_id, url, secret, events, topic_id)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING id, user_id, url, secret, events, topic_id, is_active, created_at;
+	`
+
+	newWebhook := new(models.Webhook)
+	err := s.Db.QueryRow(stmt, userID, webhook.URL, secret, pq.Array(webhook.Events), webhook.TopicID).Scan(
+		&newWebhook.ID, &newWebhook.UserID, &newWebhook.URL, &newWebhook.Secret,
+		pq.Array(&newWebhook.Events), &newWebhook.TopicID, &newWebhook.IsActive, &newWebhook.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return newWebhook, nil
+}
+
+func (s *PostgresStore) GetWebhooks(userID *int) ([]models.Webhook, error) {
+	stmt := `
+	SELECT id, user_id, url, events, topic_id, is_active, created_at
+	FROM webhooks
+	WHERE user_id IS NOT DISTINCT FROM $1
+	ORDER BY created_at DESC;
+	`
+
+	rows, err := s.Db.Query(stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []models.Webhook
+	for rows.Next() {
+		var webhook models.Webhook
+		err := rows.Scan(&webhook.ID, &webhook.UserID, &webhook.URL, pq.Array(&webhook.Events), &webhook.TopicID, &webhook.IsActive, &webhook.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (s *PostgresStore) GetWebhookByID(id int, userID *int) (*models.Webhook, error) {
+	stmt := `
+	SELECT id, user_id, url, events, topic_id, is_active, created_at
+	FROM webhooks
+	WHERE id = $1 AND user_id IS NOT DISTINCT FROM $2;
+	`
+
+	webhook := new(models.Webhook)
+	err := s.Db.QueryRow(stmt, id, userID).Scan(&webhook.ID, &webhook.UserID, &webhook.URL, pq.Array(&webhook.Events), &webhook.TopicID, &webhook.IsActive, &webhook.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (s *PostgresStore) DeleteWebhook(id int, userID *int) error {
+	res, err := s.Db.Exec("DELETE FROM webhooks WHERE id = $1 AND user_id IS NOT DISTINCT FROM $2;", id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("no webhook found to delete")
+	}
+
+	return nil
+}
+
+// GetWebhooksForEvent returns the active webhooks subscribed to the event that belong to the
+// involved users or to the admins, the secret is included to sign the deliveries. When the event has a topic the
+// webhooks of that topic get it too, unless the involved users blocked the owner or are private accounts it does
+// not follow. A webhook with a topic only gets the events of its topic
+func (s *PostgresStore) GetWebhooksForEvent(event string, userIDs []int, topicID *int) ([]models.Webhook, error) {
+	stmt := `
+	SELECT id, user_id, url, secret, events, topic_id, is_active, created_at
+	FROM webhooks w
+	WHERE is_active AND $1 = ANY(events)
+	AND CASE WHEN w.topic_id IS NOT NULL AND $3::int IS NOT NULL THEN
+		w.topic_id = $3 AND (w.user_id IS NULL OR w.user_id = ANY($2) OR (
+			NOT EXISTS (
+				SELECT 1 FROM user_blocks b
+				WHERE (b.blocker_id = w.user_id AND b.blocked_id = ANY($2)) OR (b.blocked_id = w.user_id AND b.blocker_id = ANY($2))
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM users u
+				WHERE u.id = ANY($2) AND u.is_private
+				AND NOT EXISTS (SELECT 1 FROM user_follow_user f WHERE f.user_following_id = w.user_id AND f.user_followed_id = u.id)
+			)
+		))
+	ELSE
+		w.user_id IS NULL OR w.user_id = ANY($2)
+	END;
+	`
+
+	rows, err := s.Db.Query(stmt, event, pq.Array(userIDs), topicID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []models.Webhook
+	for rows.Next() {
+		var webhook models.Webhook
+		err := rows.Scan(&webhook.ID, &webhook.UserID, &webhook.URL, &webhook.Secret, pq.Array(&webhook.Events), &webhook.TopicID, &webhook.IsActive, &webhook.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// GetActiveWebhook returns the webhook with its secret to deliver an event
+func (s *PostgresStore) GetActiveWebhook(id int) (*models.Webhook, error) {
+	stmt := `
+	SELECT id, user_id, url, secret, events, topic_id, is_active, created_at
+	FROM webhooks
+	WHERE id = $1 AND is_active;
+	`
+
+	webhook := new(models.Webhook)
+	err := s.Db.QueryRow(stmt, id).Scan(&webhook.ID, &webhook.UserID, &webhook.URL, &webhook.Secret, pq.Array(&webhook.Events), &webhook.TopicID, &webhook.IsActive, &webhook.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (s *PostgresStore) SaveWebhookDelivery(delivery *models.WebhookDelivery) error {
+	stmt := `
+	INSERT INTO webhook_deliveries (webhook_id, delivery_id, event, payload, attempt, status_code, error, success, duration_ms)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+	`
+
+	_, err := s.Db.Exec(stmt, delivery.WebhookID, delivery.DeliveryID, delivery.Event, []byte(delivery.Payload),
+		delivery.Attempt, delivery.StatusCode, delivery.Error, delivery.Success, delivery.DurationMs)
+	return err
+}
+
+func (s *PostgresStore) GetWebhookDeliveries(webhookID, limit, offset int) ([]models.WebhookDelivery, int, error) {
+	var totalCount int
+	queryCount := "SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = $1;"
+	if err := s.Db.QueryRow(queryCount, webhookID).Scan(&totalCount); err != nil {
+		return nil, 0, err
+	}
+
+	stmt := `
+	SELECT id, webhook_id, delivery_id, event, payload, attempt, status_code, error, success, duration_ms, delivered_at
+	FROM webhook_deliveries
+	WHERE webhook_id = $1
+	ORDER BY delivered_at DESC, id DESC
+	LIMIT $2 OFFSET $3;
+	`
+
+	rows, err := s.Db.Query(stmt, webhookID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		var payload []byte
+		err := rows.Scan(&delivery.ID, &delivery.WebhookID, &delivery.DeliveryID, &delivery.Event, &payload,
+			&delivery.Attempt, &delivery.StatusCode, &delivery.Error, &delivery.Success, &delivery.DurationMs, &delivery.DeliveredAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		delivery.Payload = payload
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, totalCount, nil
+}
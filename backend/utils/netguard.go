@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrNotPublicAddress is returned when a url supplied by a user points to the server or to its private network
+var ErrNotPublicAddress = errors.New("the address is not public")
+
+// cgnat is the shared address space of the carrier grade NATs (RFC 6598), it is not reachable from internet either
+var cgnat = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPublicIP tells if the ip can be reached from internet, loopback, private, link-local and unspecified
+// addresses are not public
+func IsPublicIP(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+
+	return !cgnat.Contains(ip)
+}
+
+// ParseHTTPSURL parses a url supplied by a user that the server will call, it must be https and its host must not
+// be an ip or a name of the server or its private network
+func ParseHTTPSURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return nil, fmt.Errorf("url must be an absolute https url")
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return nil, ErrNotPublicAddress
+	}
+	if ip := net.ParseIP(host); ip != nil && !IsPublicIP(ip) {
+		return nil, ErrNotPublicAddress
+	}
+
+	return u, nil
+}
+
+// CheckPublicHost resolves the host and fails if any of its addresses is not public
+func CheckPublicHost(ctx context.Context, host string) error {
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("could not resolve %s: %w", host, err)
+	}
+
+	for _, ip := range ips {
+		if !IsPublicIP(ip.IP) {
+			return ErrNotPublicAddress
+		}
+	}
+
+	return nil
+}
+
+// CheckPublicURL is ParseHTTPSURL plus the resolution of the host, for the urls checked when they are registered
+func CheckPublicURL(raw string) (*url.URL, error) {
+	u, err := ParseHTTPSURL(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := CheckPublicHost(ctx, u.Hostname()); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// NewPublicHTTPClient returns a client that only connects to public addresses. The address is checked after
+// resolving the host, right before connecting, so a name that resolves to a private address later is refused too
+func NewPublicHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !IsPublicIP(net.ParseIP(host)) {
+				return fmt.Errorf("%w: %s", ErrNotPublicAddress, host)
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		// Without proxy, a proxy would make the connection to the private address for us
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
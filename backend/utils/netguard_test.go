@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	private := []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "100.64.0.1", "0.0.0.0", "::1", "fe80::1", "fd00::1"}
+	for _, ip := range private {
+		if IsPublicIP(net.ParseIP(ip)) {
+			t.Errorf("IsPublicIP(%s) = true", ip)
+		}
+	}
+
+	public := []string{"8.8.8.8", "142.250.184.10", "2606:4700:4700::1111"}
+	for _, ip := range public {
+		if !IsPublicIP(net.ParseIP(ip)) {
+			t.Errorf("IsPublicIP(%s) = false", ip)
+		}
+	}
+}
+
+func TestParseHTTPSURL(t *testing.T) {
+	if _, err := ParseHTTPSURL("https://fcm.googleapis.com/fcm/send/abc"); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+
+	invalid := []string{"http://example.com/hook", "ftp://example.com", "https://", "not a url"}
+	for _, raw := range invalid {
+		if _, err := ParseHTTPSURL(raw); err == nil {
+			t.Errorf("expected an error for %s", raw)
+		}
+	}
+
+	notPublic := []string{"https://localhost/x", "https://api.localhost/x", "https://127.0.0.1:8080/x", "https://169.254.169.254/latest", "https://[::1]/x", "https://10.0.0.5/x"}
+	for _, raw := range notPublic {
+		if _, err := ParseHTTPSURL(raw); !errors.Is(err, ErrNotPublicAddress) {
+			t.Errorf("ParseHTTPSURL(%s) = %v, want ErrNotPublicAddress", raw, err)
+		}
+	}
+}
+
+func TestPublicHTTPClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the request reached the loopback server")
+	}))
+	defer server.Close()
+
+	_, err := NewPublicHTTPClient(time.Second).Get(server.URL)
+	if !errors.Is(err, ErrNotPublicAddress) {
+		t.Errorf("Get() error = %v, want ErrNotPublicAddress", err)
+	}
+}
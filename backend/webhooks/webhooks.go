@@ -0,0 +1,208 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/domain"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/jobs"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/storage"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/utils"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Job that posts an event to a webhook, the queue retries it with backoff
+const deliverJob = "webhook.deliver"
+
+// Name of the dispatcher among the subscribers of the domain events
+const subscriberName = "webhooks"
+
+const maxAttempts = 5
+
+type deliverPayload struct {
+	WebhookID  int             `json:"webhook_id"`
+	DeliveryID string          `json:"delivery_id"`
+	Event      string          `json:"event"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// Dispatcher posts the events to the subscribed webhooks through the job queue
+type Dispatcher struct {
+	store  storage.Storage
+	queue  *jobs.Queue
+	client *http.Client
+}
+
+func NewDispatcher(store storage.Storage, queue *jobs.Queue) *Dispatcher {
+	// The urls come from the users, the deliveries cannot reach the server or its private network
+	d := &Dispatcher{
+		store:  store,
+		queue:  queue,
+		client: utils.NewPublicHTTPClient(10 * time.Second),
+	}
+
+	jobs.Register(queue, deliverJob, d.deliver)
+
+	return d
+}
+
+// Subscribe sends the domain events that have a webhook event to the webhooks, the deliveries are only
+// queued so it runs in the relay and a failure makes the outbox relay the event again
+func (d *Dispatcher) Subscribe(bus *domain.Bus) {
+	domain.Subscribe(bus, subscriberName, func(e domain.PostCreated) error {
+		return d.Emit(e.Meta().ID, models.WebhookPostCreated, e.Post, &e.Post.Topic.ID, e.Post.User.ID)
+	})
+	domain.Subscribe(bus, subscriberName, func(e domain.EventCreated) error {
+		return d.Emit(e.Meta().ID, models.WebhookEventCreated, e.Event, &e.Event.Topic.ID, e.Event.Creator.ID)
+	})
+	domain.Subscribe(bus, subscriberName, func(e domain.EventSubscribed) error {
+		creatorID, err := d.store.GetEventCreatorID(e.EventID)
+		if err != nil {
+			return fmt.Errorf("could not get the event creator: %w", err)
+		}
+		return d.Emit(e.Meta().ID, models.WebhookEventSubscribed, models.EventSubscribedData{EventID: e.EventID, UserID: e.UserID}, nil, e.UserID, creatorID)
+	})
+	domain.Subscribe(bus, subscriberName, func(e domain.UserFollowed) error {
+		return d.Emit(e.Meta().ID, models.WebhookUserFollowed, models.UserFollowedData{FollowerID: e.FollowerID, FollowedID: e.FollowedID}, nil, e.FollowerID, e.FollowedID)
+	})
+}
+
+// Emit queues the delivery of the event to the webhooks of the involved users, of the admins and of its topic.
+// The delivery id is derived from the event id, so a relayed duplicate has the same id and receivers can ignore it
+func (d *Dispatcher) Emit(eventID, event string, data any, topicID *int, userIDs ...int) error {
+	webhooks, err := d.store.GetWebhooksForEvent(event, userIDs, topicID)
+	if err != nil {
+		return fmt.Errorf("could not get the webhooks: %w", err)
+	}
+
+	for _, webhook := range webhooks {
+		deliveryID := fmt.Sprintf("%s-%d", eventID, webhook.ID)
+
+		body, err := json.Marshal(models.WebhookPayload{
+			ID:        deliveryID,
+			Event:     event,
+			CreatedAt: time.Now().UTC(),
+			Data:      data,
+		})
+		if err != nil {
+			return fmt.Errorf("could not encode the payload: %w", err)
+		}
+
+		payload := deliverPayload{WebhookID: webhook.ID, DeliveryID: deliveryID, Event: event, Body: body}
+		if _, err := d.queue.Enqueue(deliverJob, payload, jobs.MaxAttempts(maxAttempts)); err != nil {
+			return fmt.Errorf("could not queue the delivery: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// deliver makes one attempt and logs it, failing makes the queue retry it later
+func (d *Dispatcher) deliver(job *models.Job, p deliverPayload) error {
+	webhook, err := d.store.GetActiveWebhook(p.WebhookID)
+	if err == sql.ErrNoRows {
+		// The webhook was deleted or disabled after the event
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	delivery := d.attempt(*webhook, p.Event, p.DeliveryID, p.Body)
+	delivery.Attempt = job.Attempts
+
+	if err := d.store.SaveWebhookDelivery(delivery); err != nil {
+		log.Println("webhooks: could not save the delivery:", err)
+	}
+
+	if !delivery.Success {
+		return errors.New(*delivery.Error)
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) attempt(webhook models.Webhook, event, deliveryID string, body []byte) *models.WebhookDelivery {
+	delivery := &models.WebhookDelivery{
+		WebhookID:  webhook.ID,
+		DeliveryID: deliveryID,
+		Event:      event,
+		Payload:    body,
+	}
+
+	fail := func(err error) *models.WebhookDelivery {
+		msg := err.Error()
+		delivery.Error = &msg
+		return delivery
+	}
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fail(err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderDelivery, deliveryID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, body))
+
+	start := time.Now()
+	res, err := d.client.Do(req)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		return fail(err)
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 1<<16))
+
+	delivery.StatusCode = &res.StatusCode
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fail(fmt.Errorf("unexpected status %d", res.StatusCode))
+	}
+
+	delivery.Success = true
+	return delivery
+}
+
+// Sign returns the signature of the delivery, receivers compute the HMAC-SHA256 of
+// "<timestamp>.<body>" with their secret and compare it with the header
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewID returns a random hex id, used for the secrets and the delivery ids
+func NewID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,24 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"post.created"}`)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1700000000." + string(body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	if got := Sign("secret", "1700000000", body); got != want {
+		t.Errorf("Sign() = %s, want %s", got, want)
+	}
+
+	if Sign("other", "1700000000", body) == want {
+		t.Error("the signature does not depend on the secret")
+	}
+}
@@ -0,0 +1,53 @@
+package webpush
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/Marc-Garcia-Coronado/socialNetwork/models"
+	"github.com/Marc-Garcia-Coronado/socialNetwork/storage"
+)
+
+// Dispatcher sends a payload to every push subscription of a user
+type Dispatcher struct {
+	store  storage.Storage
+	sender *Sender
+}
+
+func NewDispatcher(store storage.Storage, sender *Sender) *Dispatcher {
+	return &Dispatcher{
+		store:  store,
+		sender: sender,
+	}
+}
+
+// Push delivers the payload in the background, the subscriptions that are gone are removed
+func (d *Dispatcher) Push(userID int, payload *models.PushPayload) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Println("webpush: could not encode the payload:", err)
+		return
+	}
+
+	subscriptions, err := d.store.GetPushSubscriptions(userID)
+	if err != nil {
+		log.Println("webpush: could not get the subscriptions:", err)
+		return
+	}
+
+	for _, sub := range subscriptions {
+		go func(sub models.PushSubscription) {
+			err := d.sender.Send(sub.Endpoint, sub.Keys.P256dh, sub.Keys.Auth, data)
+			if errors.Is(err, ErrGone) {
+				if err := d.store.DeletePushSubscriptionByEndpoint(sub.Endpoint); err != nil {
+					log.Println("webpush: could not delete the subscription:", err)
+				}
+				return
+			}
+			if err != nil {
+				log.Println("webpush: could not send the push:", err)
+			}
+		}(sub)
+	}
+}
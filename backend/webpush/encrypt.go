@@ -0,0 +1,126 @@
+package webpush
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// Record size of the encrypted content, the payload always fits in a single record
+const recordSize = 4096
+
+// Push services accept 4096 bytes of body: the 86 bytes header, the payload, the delimiter and the 16 bytes tag
+const maxPayloadSize = 4096 - 86 - 1 - 16
+
+// encrypt encrypts the payload for the subscription keys with the aes128gcm content coding (RFC 8291)
+func encrypt(payload []byte, p256dh, auth string) ([]byte, error) {
+	if len(payload) > maxPayloadSize {
+		return nil, fmt.Errorf("payload too large: %d bytes", len(payload))
+	}
+
+	uaPublicBytes, err := decodeKey(p256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+
+	authSecret, err := decodeKey(auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth secret: %w", err)
+	}
+
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+
+	// Every message uses a new key pair and salt
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublicBytes := asPrivate.PublicKey().Bytes()
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	ecdhSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	keyInfo := "WebPush: info\x00" + string(uaPublicBytes) + string(asPublicBytes)
+	ikm, err := hkdf.Key(sha256.New, ecdhSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	cek, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// 0x02 marks the last (and only) record
+	plaintext := append(append([]byte{}, payload...), 0x02)
+
+	// Header: salt || record size || key id length || key id
+	body := make([]byte, 0, 16+4+1+len(asPublicBytes)+len(plaintext)+gcm.Overhead())
+	body = append(body, salt...)
+	body = binary.BigEndian.AppendUint32(body, recordSize)
+	body = append(body, byte(len(asPublicBytes)))
+	body = append(body, asPublicBytes...)
+
+	return gcm.Seal(body, nonce, plaintext, nil), nil
+}
+
+// decodeKey accepts the keys with or without padding, browsers send base64url
+func decodeKey(key string) ([]byte, error) {
+	key = strings.TrimRight(key, "=")
+	if decoded, err := base64.RawURLEncoding.DecodeString(key); err == nil {
+		return decoded, nil
+	}
+
+	return base64.RawStdEncoding.DecodeString(key)
+}
+
+// ValidateKeys checks the keys of a subscription when it is registered, p256dh must be an uncompressed P-256
+// point and auth a 16 bytes secret
+func ValidateKeys(p256dh, auth string) error {
+	publicKey, err := decodeKey(p256dh)
+	if err != nil || len(publicKey) != 65 || publicKey[0] != 0x04 {
+		return fmt.Errorf("invalid p256dh key: it must be an uncompressed P-256 point")
+	}
+	if _, err := ecdh.P256().NewPublicKey(publicKey); err != nil {
+		return fmt.Errorf("invalid p256dh key: %w", err)
+	}
+
+	authSecret, err := decodeKey(auth)
+	if err != nil || len(authSecret) != 16 {
+		return fmt.Errorf("invalid auth secret: it must be 16 bytes")
+	}
+
+	return nil
+}
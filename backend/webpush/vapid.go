@@ -0,0 +1,89 @@
+package webpush
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// VAPIDKeys identify the application server to the push services (RFC 8292)
+type VAPIDKeys struct {
+	private *ecdsa.PrivateKey
+	// PublicKey is the uncompressed P-256 point encoded in base64url, the frontend
+	// uses it as applicationServerKey when subscribing
+	PublicKey string
+}
+
+// GenerateVAPIDKeys creates a new key pair and returns the private key encoded in base64url
+func GenerateVAPIDKeys() (*VAPIDKeys, string, error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, "", err
+	}
+
+	privateKey := base64.RawURLEncoding.EncodeToString(key.Bytes())
+	keys, err := ParseVAPIDKeys(privateKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return keys, privateKey, nil
+}
+
+// ParseVAPIDKeys builds the key pair from the raw private key encoded in base64url,
+// the format used by the web-push tools to generate keys
+func ParseVAPIDKeys(privateKey string) (*VAPIDKeys, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vapid private key: %w", err)
+	}
+
+	key, err := ecdh.P256().NewPrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vapid private key: %w", err)
+	}
+
+	// The public key is 0x04 || X || Y
+	public := key.PublicKey().Bytes()
+
+	return &VAPIDKeys{
+		private: &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{
+				Curve: elliptic.P256(),
+				X:     new(big.Int).SetBytes(public[1:33]),
+				Y:     new(big.Int).SetBytes(public[33:]),
+			},
+			D: new(big.Int).SetBytes(raw),
+		},
+		PublicKey: base64.RawURLEncoding.EncodeToString(public),
+	}, nil
+}
+
+// authorization returns the Authorization header for the push service of the endpoint
+func (k *VAPIDKeys) authorization(endpoint, subject string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(12 * time.Hour).Unix(),
+		"sub": subject,
+	})
+
+	signed, err := token.SignedString(k.private)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("vapid t=%s, k=%s", signed, k.PublicKey), nil
+}
@@ -0,0 +1,98 @@
+package webpush
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ErrGone is returned when the push service says the subscription does not exist anymore
+var ErrGone = errors.New("push subscription is gone")
+
+// Seconds the push service keeps the message while the device is offline
+const defaultTTL = 24 * 60 * 60
+
+// Sender delivers encrypted payloads to the push services of the subscriptions
+type Sender struct {
+	keys    *VAPIDKeys
+	subject string
+	client  *http.Client
+}
+
+func NewSender(keys *VAPIDKeys, subject string) *Sender {
+	return &Sender{
+		keys:    keys,
+		subject: subject,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// NewSenderFromEnv reads VAPID_PRIVATE_KEY and VAPID_SUBJECT. Without a key push stays disabled, a generated one
+// would change on every restart and on every replica and break the subscriptions made with it
+func NewSenderFromEnv() (*Sender, error) {
+	subject := os.Getenv("VAPID_SUBJECT")
+	if subject == "" {
+		subject = "mailto:admin@socialnetwork.local"
+	}
+
+	privateKey := os.Getenv("VAPID_PRIVATE_KEY")
+	if privateKey == "" {
+		return nil, errors.New("VAPID_PRIVATE_KEY is not set")
+	}
+
+	keys, err := ParseVAPIDKeys(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSender(keys, subject), nil
+}
+
+// PublicKey is the applicationServerKey the frontend needs to subscribe
+func (s *Sender) PublicKey() string {
+	return s.keys.PublicKey
+}
+
+// Send encrypts the payload for the subscription and posts it to its endpoint
+func (s *Sender) Send(endpoint, p256dh, auth string, payload []byte) error {
+	body, err := encrypt(payload, p256dh, auth)
+	if err != nil {
+		return err
+	}
+
+	authorization, err := s.keys.authorization(endpoint, s.subject, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(defaultTTL))
+	req.Header.Set("Urgency", "normal")
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone:
+		return ErrGone
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		return nil
+	default:
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("push service answered %d: %s", res.StatusCode, msg)
+	}
+}
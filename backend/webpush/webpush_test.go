@@ -0,0 +1,170 @@
+package webpush
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeSubscription is the browser side of a subscription
+type fakeSubscription struct {
+	private *ecdh.PrivateKey
+	auth    []byte
+}
+
+func newFakeSubscription(t *testing.T) *fakeSubscription {
+	t.Helper()
+
+	private, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := make([]byte, 16)
+	rand.Read(auth)
+
+	return &fakeSubscription{private: private, auth: auth}
+}
+
+func (f *fakeSubscription) keys() (string, string) {
+	return base64.RawURLEncoding.EncodeToString(f.private.PublicKey().Bytes()), base64.RawURLEncoding.EncodeToString(f.auth)
+}
+
+// decrypt does what the browser does with the aes128gcm body
+func (f *fakeSubscription) decrypt(t *testing.T, body []byte) []byte {
+	t.Helper()
+
+	salt := body[:16]
+	if rs := binary.BigEndian.Uint32(body[16:20]); rs != recordSize {
+		t.Fatalf("unexpected record size %d", rs)
+	}
+	idLen := int(body[20])
+	asPublicBytes := body[21 : 21+idLen]
+	ciphertext := body[21+idLen:]
+
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := f.private.ECDH(asPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyInfo := "WebPush: info\x00" + string(f.private.PublicKey().Bytes()) + string(asPublicBytes)
+	ikm, _ := hkdf.Key(sha256.New, secret, f.auth, keyInfo, 32)
+	cek, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	nonce, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if plaintext[len(plaintext)-1] != 0x02 {
+		t.Fatal("missing last record delimiter")
+	}
+
+	return plaintext[:len(plaintext)-1]
+}
+
+func TestSendDeliversEncryptedPayload(t *testing.T) {
+	keys, _, err := GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := NewSender(keys, "mailto:test@example.com")
+	sub := newFakeSubscription(t)
+
+	var received []byte
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "aes128gcm" || r.Header.Get("TTL") == "" {
+			t.Errorf("missing push headers: %v", r.Header)
+		}
+		authorization = r.Header.Get("Authorization")
+		received, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	p256dh, auth := sub.keys()
+	if err := sender.Send(server.URL+"/push/abc", p256dh, auth, []byte(`{"title":"hi"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := sub.decrypt(t, received); string(got) != `{"title":"hi"}` {
+		t.Errorf("unexpected payload %q", got)
+	}
+
+	// The VAPID token is signed with the key the frontend subscribed with
+	token, found := strings.CutPrefix(authorization, "vapid t=")
+	if !found || !strings.HasSuffix(token, ", k="+keys.PublicKey) {
+		t.Fatalf("unexpected authorization header %q", authorization)
+	}
+	token = strings.TrimSuffix(token, ", k="+keys.PublicKey)
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return &keys.private.PublicKey, nil
+	}, jwt.WithValidMethods([]string{"ES256"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims["aud"] != server.URL {
+		t.Errorf("unexpected audience %v", claims["aud"])
+	}
+}
+
+func TestSendReturnsErrGone(t *testing.T) {
+	keys, _, err := GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := NewSender(keys, "mailto:test@example.com")
+	sub := newFakeSubscription(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	p256dh, auth := sub.keys()
+	if err := sender.Send(server.URL, p256dh, auth, []byte("{}")); !errors.Is(err, ErrGone) {
+		t.Errorf("expected ErrGone, got %v", err)
+	}
+}
+
+func TestValidateKeys(t *testing.T) {
+	sub := newFakeSubscription(t)
+	p256dh, auth := sub.keys()
+	if err := ValidateKeys(p256dh, auth); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	compressed := base64.RawURLEncoding.EncodeToString(append([]byte{0x02}, sub.private.PublicKey().Bytes()[1:33]...))
+	if err := ValidateKeys(compressed, auth); err == nil {
+		t.Error("expected an error for a compressed point")
+	}
+
+	if err := ValidateKeys(p256dh, base64.RawURLEncoding.EncodeToString([]byte("short"))); err == nil {
+		t.Error("expected an error for a short auth secret")
+	}
+}